@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/GzzyZm/interpreter/token"
+	"math/big"
 	"strings"
 )
 
@@ -13,6 +14,10 @@ type Node interface {
 	TokenLiteral() string
 	// PrintNode used to compare ast nodes or print ast nodes when debugging
 	PrintNode() string
+	// Pos return the position of the first character belonging to the node
+	Pos() token.Position
+	// End return the position of the first character immediately after the node
+	End() token.Position
 }
 
 type Statement interface {
@@ -28,6 +33,7 @@ type Expression interface {
 // Program the program acts as the root node for all nodes generated by parsing
 type Program struct {
 	Statements []Statement
+	Comments   []*Comment // the comments in source order, only collected when the lexer scans comments
 }
 
 func (p *Program) TokenLiteral() string {
@@ -36,6 +42,18 @@ func (p *Program) TokenLiteral() string {
 	}
 	return ""
 }
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
 func (p *Program) PrintNode() string {
 	var out bytes.Buffer
 	for _, statement := range p.Statements {
@@ -44,19 +62,56 @@ func (p *Program) PrintNode() string {
 	return out.String()
 }
 
+// Comment a // line comment or a /* block */ comment, the text includes the delimiters
+type Comment struct {
+	Token token.Token // COMMENT lexical unit
+}
+
+func (c *Comment) TokenLiteral() string { return c.Token.Literal }
+func (c *Comment) Pos() token.Position  { return c.Token.Pos }
+func (c *Comment) End() token.Position  { return c.Token.End }
+func (c *Comment) PrintNode() string    { return c.Token.Literal }
+
 // Statement node type
 
+// LetStatement let x = v or const x = v, depending on Token.
+// a destructuring statement like let [a, b] = v has a Pattern instead of a Name
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token
+	Name    *Identifier
+	Pattern Pattern
+	Value   Expression
+}
+
+// IsConst report whether the statement declares a constant
+func (l *LetStatement) IsConst() bool { return l.Token.Type == token.CONST }
+
+// Target return the Pattern or the Name
+func (l *LetStatement) Target() Pattern {
+	if l.Pattern != nil {
+		return l.Pattern
+	}
+	return l.Name
 }
 
 func (l *LetStatement) statementNode()       {}
 func (l *LetStatement) TokenLiteral() string { return l.Token.Literal }
+func (l *LetStatement) Pos() token.Position  { return l.Token.Pos }
+func (l *LetStatement) End() token.Position {
+	if l.Value != nil {
+		return l.Value.End()
+	}
+	if l.Pattern != nil {
+		return l.Pattern.End()
+	}
+	if l.Name != nil {
+		return l.Name.End()
+	}
+	return l.Token.End
+}
 func (l *LetStatement) PrintNode() string {
 	var out bytes.Buffer
-	out.WriteString(l.TokenLiteral() + " " + l.Name.PrintNode() + " = ")
+	out.WriteString(l.TokenLiteral() + " " + l.Target().PrintNode() + " = ")
 	if l.Value != nil {
 		out.WriteString(l.Value.PrintNode())
 	}
@@ -71,6 +126,13 @@ type ReturnStatement struct {
 
 func (r *ReturnStatement) statementNode()       {}
 func (r *ReturnStatement) TokenLiteral() string { return r.Token.Literal }
+func (r *ReturnStatement) Pos() token.Position  { return r.Token.Pos }
+func (r *ReturnStatement) End() token.Position {
+	if r.ReturnValue != nil {
+		return r.ReturnValue.End()
+	}
+	return r.Token.End
+}
 func (r *ReturnStatement) PrintNode() string {
 	var out bytes.Buffer
 	out.WriteString(r.TokenLiteral() + " ")
@@ -84,10 +146,21 @@ func (r *ReturnStatement) PrintNode() string {
 type BlockStatement struct {
 	Token      token.Token // '{' lexical unit
 	Statements []Statement
+	RBrace     token.Token // '}' lexical unit
 }
 
 func (b *BlockStatement) statementNode()       {}
 func (b *BlockStatement) TokenLiteral() string { return b.Token.Literal }
+func (b *BlockStatement) Pos() token.Position  { return b.Token.Pos }
+func (b *BlockStatement) End() token.Position {
+	if b.RBrace.End.IsValid() {
+		return b.RBrace.End
+	}
+	if len(b.Statements) > 0 {
+		return b.Statements[len(b.Statements)-1].End()
+	}
+	return b.Token.End
+}
 func (b *BlockStatement) PrintNode() string {
 	var out bytes.Buffer
 	for _, statement := range b.Statements {
@@ -96,6 +169,67 @@ func (b *BlockStatement) PrintNode() string {
 	return out.String()
 }
 
+// WhileStatement while (cond) { ... }
+type WhileStatement struct {
+	Token     token.Token // 'while' lexical unit
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (w *WhileStatement) statementNode()       {}
+func (w *WhileStatement) TokenLiteral() string { return w.Token.Literal }
+func (w *WhileStatement) Pos() token.Position  { return w.Token.Pos }
+func (w *WhileStatement) End() token.Position {
+	if w.Body != nil {
+		return w.Body.End()
+	}
+	return w.Token.End
+}
+func (w *WhileStatement) PrintNode() string {
+	return fmt.Sprintf("while %s %s", w.Condition.PrintNode(), w.Body.PrintNode())
+}
+
+// ForStatement for (x in iterable) { ... }
+type ForStatement struct {
+	Token    token.Token // 'for' lexical unit
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (f *ForStatement) statementNode()       {}
+func (f *ForStatement) TokenLiteral() string { return f.Token.Literal }
+func (f *ForStatement) Pos() token.Position  { return f.Token.Pos }
+func (f *ForStatement) End() token.Position {
+	if f.Body != nil {
+		return f.Body.End()
+	}
+	return f.Token.End
+}
+func (f *ForStatement) PrintNode() string {
+	return fmt.Sprintf("for %s in %s %s", f.Variable.PrintNode(), f.Iterable.PrintNode(), f.Body.PrintNode())
+}
+
+type BreakStatement struct {
+	Token token.Token // 'break' lexical unit
+}
+
+func (b *BreakStatement) statementNode()       {}
+func (b *BreakStatement) TokenLiteral() string { return b.Token.Literal }
+func (b *BreakStatement) Pos() token.Position  { return b.Token.Pos }
+func (b *BreakStatement) End() token.Position  { return b.Token.End }
+func (b *BreakStatement) PrintNode() string    { return b.TokenLiteral() + ";" }
+
+type ContinueStatement struct {
+	Token token.Token // 'continue' lexical unit
+}
+
+func (c *ContinueStatement) statementNode()       {}
+func (c *ContinueStatement) TokenLiteral() string { return c.Token.Literal }
+func (c *ContinueStatement) Pos() token.Position  { return c.Token.Pos }
+func (c *ContinueStatement) End() token.Position  { return c.Token.End }
+func (c *ContinueStatement) PrintNode() string    { return c.TokenLiteral() + ";" }
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -103,6 +237,13 @@ type ExpressionStatement struct {
 
 func (e *ExpressionStatement) statementNode()       {}
 func (e *ExpressionStatement) TokenLiteral() string { return e.Token.Literal }
+func (e *ExpressionStatement) Pos() token.Position  { return e.Token.Pos }
+func (e *ExpressionStatement) End() token.Position {
+	if e.Expression != nil {
+		return e.Expression.End()
+	}
+	return e.Token.End
+}
 func (e *ExpressionStatement) PrintNode() string {
 	if e.Expression != nil {
 		return e.Expression.PrintNode()
@@ -120,15 +261,42 @@ type Boolean struct {
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) PrintNode() string    { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) End() token.Position  { return b.Token.End }
 
 type Integer struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int // set instead of Value when the literal doesn't fit into int64
 }
 
 func (i *Integer) expressionNode()      {}
 func (i *Integer) TokenLiteral() string { return i.Token.Literal }
 func (i *Integer) PrintNode() string    { return i.Token.Literal }
+func (i *Integer) Pos() token.Position  { return i.Token.Pos }
+func (i *Integer) End() token.Position  { return i.Token.End }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) expressionNode()      {}
+func (f *FloatLiteral) TokenLiteral() string { return f.Token.Literal }
+func (f *FloatLiteral) PrintNode() string    { return f.Token.Literal }
+func (f *FloatLiteral) Pos() token.Position  { return f.Token.Pos }
+func (f *FloatLiteral) End() token.Position  { return f.Token.End }
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (s *StringLiteral) expressionNode()      {}
+func (s *StringLiteral) TokenLiteral() string { return s.Token.Literal }
+func (s *StringLiteral) PrintNode() string    { return s.Token.Literal }
+func (s *StringLiteral) Pos() token.Position  { return s.Token.Pos }
+func (s *StringLiteral) End() token.Position  { return s.Token.End }
 
 type Identifier struct {
 	Token token.Token
@@ -136,8 +304,11 @@ type Identifier struct {
 }
 
 func (i *Identifier) expressionNode()      {}
+func (i *Identifier) patternNode()         {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) PrintNode() string    { return i.Value }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
+func (i *Identifier) End() token.Position  { return i.Token.End }
 
 type PrefixExpression struct {
 	Token     token.Token
@@ -147,12 +318,40 @@ type PrefixExpression struct {
 
 func (p *PrefixExpression) expressionNode()      {}
 func (p *PrefixExpression) TokenLiteral() string { return p.Token.Literal }
+func (p *PrefixExpression) Pos() token.Position  { return p.Token.Pos }
+func (p *PrefixExpression) End() token.Position {
+	if p.RightExpr != nil {
+		return p.RightExpr.End()
+	}
+	return p.Token.End
+}
 func (p *PrefixExpression) PrintNode() string {
 	var out bytes.Buffer
 	out.WriteString(fmt.Sprintf("(%s%s)", p.Operator, p.RightExpr.PrintNode()))
 	return out.String()
 }
 
+// AssignExpression x = v, x += v or a[i] = v, Target is an Identifier or an IndexExpression
+type AssignExpression struct {
+	Token    token.Token // the assignment operator
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (a *AssignExpression) expressionNode()      {}
+func (a *AssignExpression) TokenLiteral() string { return a.Token.Literal }
+func (a *AssignExpression) Pos() token.Position  { return a.Target.Pos() }
+func (a *AssignExpression) End() token.Position {
+	if a.Value != nil {
+		return a.Value.End()
+	}
+	return a.Token.End
+}
+func (a *AssignExpression) PrintNode() string {
+	return fmt.Sprintf("(%s %s %s)", a.Target.PrintNode(), a.Operator, a.Value.PrintNode())
+}
+
 type InfixExpression struct {
 	Token     token.Token
 	LeftExpr  Expression
@@ -162,6 +361,18 @@ type InfixExpression struct {
 
 func (i *InfixExpression) expressionNode()      {}
 func (i *InfixExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *InfixExpression) Pos() token.Position {
+	if i.LeftExpr != nil {
+		return i.LeftExpr.Pos()
+	}
+	return i.Token.Pos
+}
+func (i *InfixExpression) End() token.Position {
+	if i.RightExpr != nil {
+		return i.RightExpr.End()
+	}
+	return i.Token.End
+}
 func (i *InfixExpression) PrintNode() string {
 	var out bytes.Buffer
 	out.WriteString(fmt.Sprintf("(%s %s %s)", i.LeftExpr.PrintNode(), i.Operator, i.RightExpr.PrintNode()))
@@ -177,6 +388,16 @@ type IfExpression struct {
 
 func (i *IfExpression) expressionNode()      {}
 func (i *IfExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *IfExpression) Pos() token.Position  { return i.Token.Pos }
+func (i *IfExpression) End() token.Position {
+	if i.Alternative != nil {
+		return i.Alternative.End()
+	}
+	if i.Consequence != nil {
+		return i.Consequence.End()
+	}
+	return i.Token.End
+}
 func (i *IfExpression) PrintNode() string {
 	var out bytes.Buffer
 	out.WriteString(fmt.Sprintf("if %s %s", i.Condition.PrintNode(), i.Consequence.PrintNode()))
@@ -186,34 +407,249 @@ func (i *IfExpression) PrintNode() string {
 	return out.String()
 }
 
+// Wildcard the match pattern which matches any value without binding it
+const Wildcard = "_"
+
+// MatchArm Patterns [if Guard] => Body, a pattern is a literal, the Wildcard or an Identifier binding the subject
+type MatchArm struct {
+	Patterns []Expression
+	Guard    Expression
+	Body     *BlockStatement
+}
+
+func (m *MatchArm) PrintNode() string {
+	var patterns []string
+	for _, pattern := range m.Patterns {
+		patterns = append(patterns, pattern.PrintNode())
+	}
+	var out bytes.Buffer
+	out.WriteString(strings.Join(patterns, ", "))
+	if m.Guard != nil {
+		out.WriteString(" if " + m.Guard.PrintNode())
+	}
+	out.WriteString(" => " + m.Body.PrintNode())
+	return out.String()
+}
+
+// MatchExpression match (Subject) { arm, ... }, the first matching arm is evaluated
+type MatchExpression struct {
+	Token   token.Token // 'match' lexical unit
+	Subject Expression
+	Arms    []*MatchArm
+	RBrace  token.Token // '}' lexical unit
+}
+
+func (m *MatchExpression) expressionNode()      {}
+func (m *MatchExpression) TokenLiteral() string { return m.Token.Literal }
+func (m *MatchExpression) Pos() token.Position  { return m.Token.Pos }
+func (m *MatchExpression) End() token.Position {
+	if m.RBrace.End.IsValid() {
+		return m.RBrace.End
+	}
+	return m.Token.End
+}
+func (m *MatchExpression) PrintNode() string {
+	var arms []string
+	for _, arm := range m.Arms {
+		arms = append(arms, arm.PrintNode())
+	}
+	return fmt.Sprintf("match %s { %s }", m.Subject.PrintNode(), strings.Join(arms, ", "))
+}
+
 type FunctionLiteral struct {
 	Token      token.Token
-	Parameters []*Identifier
+	Name       *Identifier // the name of a function declaration, nil for an anonymous function
+	Parameters []Pattern
+	Defaults   []Expression // default value of each parameter, nil for the required ones
+	Rest       *Identifier  // the ...rest parameter collecting the extra arguments, nil if there is none
 	Body       *BlockStatement
 }
 
 func (f *FunctionLiteral) expressionNode()      {}
 func (f *FunctionLiteral) TokenLiteral() string { return f.Token.Literal }
+func (f *FunctionLiteral) Pos() token.Position  { return f.Token.Pos }
+func (f *FunctionLiteral) End() token.Position {
+	if f.Body != nil {
+		return f.Body.End()
+	}
+	return f.Token.End
+}
 func (f *FunctionLiteral) PrintNode() string {
-	var (
-		out    bytes.Buffer
-		params []string
-	)
-	for _, p := range f.Parameters {
-		params = append(params, p.PrintNode())
+	var out bytes.Buffer
+	out.WriteString(f.TokenLiteral())
+	if f.Name != nil {
+		out.WriteString(" " + f.Name.PrintNode())
 	}
-	out.WriteString(fmt.Sprintf("%s(%s) %s", f.TokenLiteral(), strings.Join(params, ", "), f.Body.PrintNode()))
+	out.WriteString(fmt.Sprintf("(%s) %s", PrintParameters(f.Parameters, f.Defaults, f.Rest), f.Body.PrintNode()))
 	return out.String()
 }
 
+// MacroLiteral macro(params) { ... }, it is only allowed as the value of a top-level let statement
+type MacroLiteral struct {
+	Token      token.Token // 'macro' lexical unit
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (m *MacroLiteral) expressionNode()      {}
+func (m *MacroLiteral) TokenLiteral() string { return m.Token.Literal }
+func (m *MacroLiteral) Pos() token.Position  { return m.Token.Pos }
+func (m *MacroLiteral) End() token.Position {
+	if m.Body != nil {
+		return m.Body.End()
+	}
+	return m.Token.End
+}
+func (m *MacroLiteral) PrintNode() string {
+	var params []string
+	for _, p := range m.Parameters {
+		params = append(params, p.PrintNode())
+	}
+	return fmt.Sprintf("%s(%s) %s", m.TokenLiteral(), strings.Join(params, ", "), m.Body.PrintNode())
+}
+
+// FunctionStatement fn name(params) { ... }, the declaration is hoisted to the start of its block
+type FunctionStatement struct {
+	Function *FunctionLiteral
+}
+
+func (f *FunctionStatement) statementNode()       {}
+func (f *FunctionStatement) TokenLiteral() string { return f.Function.TokenLiteral() }
+func (f *FunctionStatement) Pos() token.Position  { return f.Function.Pos() }
+func (f *FunctionStatement) End() token.Position  { return f.Function.End() }
+func (f *FunctionStatement) PrintNode() string    { return f.Function.PrintNode() }
+
+// PrintParameters print a parameter list, e.g. "a, b = 10, ...rest", rest may be nil
+func PrintParameters(parameters []Pattern, defaults []Expression, rest *Identifier) string {
+	var params []string
+	for i, p := range parameters {
+		if i < len(defaults) && defaults[i] != nil {
+			params = append(params, p.PrintNode()+" = "+defaults[i].PrintNode())
+		} else {
+			params = append(params, p.PrintNode())
+		}
+	}
+	if rest != nil {
+		params = append(params, "..."+rest.PrintNode())
+	}
+	return strings.Join(params, ", ")
+}
+
+// SpreadExpression ...array in the arguments of a call or the elements of an array literal
+type SpreadExpression struct {
+	Token token.Token // '...' lexical unit
+	Value Expression
+}
+
+func (s *SpreadExpression) expressionNode()      {}
+func (s *SpreadExpression) TokenLiteral() string { return s.Token.Literal }
+func (s *SpreadExpression) Pos() token.Position  { return s.Token.Pos }
+func (s *SpreadExpression) End() token.Position {
+	if s.Value != nil {
+		return s.Value.End()
+	}
+	return s.Token.End
+}
+func (s *SpreadExpression) PrintNode() string { return s.TokenLiteral() + s.Value.PrintNode() }
+
+type ArrayLiteral struct {
+	Token    token.Token // '[' lexical unit
+	Elements []Expression
+	RBracket token.Token // ']' lexical unit
+}
+
+func (a *ArrayLiteral) expressionNode()      {}
+func (a *ArrayLiteral) TokenLiteral() string { return a.Token.Literal }
+func (a *ArrayLiteral) Pos() token.Position  { return a.Token.Pos }
+func (a *ArrayLiteral) End() token.Position {
+	if a.RBracket.End.IsValid() {
+		return a.RBracket.End
+	}
+	return a.Token.End
+}
+func (a *ArrayLiteral) PrintNode() string {
+	var elements []string
+	for _, e := range a.Elements {
+		elements = append(elements, e.PrintNode())
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+type IndexExpression struct {
+	Token    token.Token // '[' lexical unit
+	Left     Expression
+	Index    Expression
+	RBracket token.Token // ']' lexical unit
+}
+
+func (i *IndexExpression) expressionNode()      {}
+func (i *IndexExpression) TokenLiteral() string { return i.Token.Literal }
+func (i *IndexExpression) Pos() token.Position {
+	if i.Left != nil {
+		return i.Left.Pos()
+	}
+	return i.Token.Pos
+}
+func (i *IndexExpression) End() token.Position {
+	if i.RBracket.End.IsValid() {
+		return i.RBracket.End
+	}
+	return i.Token.End
+}
+func (i *IndexExpression) PrintNode() string {
+	return fmt.Sprintf("(%s[%s])", i.Left.PrintNode(), i.Index.PrintNode())
+}
+
+// HashPair a key-value pair of a hash literal, kept in source order
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+type HashLiteral struct {
+	Token  token.Token // '{' lexical unit
+	Pairs  []HashPair
+	RBrace token.Token // '}' lexical unit
+}
+
+func (h *HashLiteral) expressionNode()      {}
+func (h *HashLiteral) TokenLiteral() string { return h.Token.Literal }
+func (h *HashLiteral) Pos() token.Position  { return h.Token.Pos }
+func (h *HashLiteral) End() token.Position {
+	if h.RBrace.End.IsValid() {
+		return h.RBrace.End
+	}
+	return h.Token.End
+}
+func (h *HashLiteral) PrintNode() string {
+	var pairs []string
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair.Key.PrintNode()+": "+pair.Value.PrintNode())
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
 type CallExpression struct {
 	Function  Expression  // identifier or function literal
 	Token     token.Token // '(' lexical unit
 	Arguments []Expression
+	RParen    token.Token // ')' lexical unit
 }
 
 func (c *CallExpression) expressionNode()      {}
 func (c *CallExpression) TokenLiteral() string { return c.Token.Literal }
+func (c *CallExpression) Pos() token.Position {
+	if c.Function != nil {
+		return c.Function.Pos()
+	}
+	return c.Token.Pos
+}
+func (c *CallExpression) End() token.Position {
+	if c.RParen.End.IsValid() {
+		return c.RParen.End
+	}
+	return c.Token.End
+}
 func (c *CallExpression) PrintNode() string {
 	var (
 		out  bytes.Buffer
@@ -0,0 +1,225 @@
+package ast
+
+import "fmt"
+
+// Copy return a deep copy of the node, so it can be rewritten without changing the original.
+// the tokens and the values of the literals are shared, they are never modified
+func Copy(node Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		c := *n
+		c.Statements = copyStatements(n.Statements)
+		return &c
+	case *BlockStatement:
+		return copyBlock(n)
+	case *LetStatement:
+		c := *n
+		c.Name = copyIdentifier(n.Name)
+		if n.Pattern != nil {
+			c.Pattern = Copy(n.Pattern).(Pattern)
+		}
+		c.Value = copyExpression(n.Value)
+		return &c
+	case *ReturnStatement:
+		c := *n
+		c.ReturnValue = copyExpression(n.ReturnValue)
+		return &c
+	case *ExpressionStatement:
+		c := *n
+		c.Expression = copyExpression(n.Expression)
+		return &c
+	case *WhileStatement:
+		c := *n
+		c.Condition = copyExpression(n.Condition)
+		c.Body = copyBlock(n.Body)
+		return &c
+	case *ForStatement:
+		c := *n
+		c.Variable = copyIdentifier(n.Variable)
+		c.Iterable = copyExpression(n.Iterable)
+		c.Body = copyBlock(n.Body)
+		return &c
+	case *FunctionStatement:
+		return &FunctionStatement{Function: copyFunction(n.Function)}
+	case *BreakStatement:
+		c := *n
+		return &c
+	case *ContinueStatement:
+		c := *n
+		return &c
+
+	case *Identifier:
+		return copyIdentifier(n)
+	case *Integer:
+		c := *n
+		return &c
+	case *FloatLiteral:
+		c := *n
+		return &c
+	case *StringLiteral:
+		c := *n
+		return &c
+	case *Boolean:
+		c := *n
+		return &c
+	case *Comment:
+		c := *n
+		return &c
+
+	case *PrefixExpression:
+		c := *n
+		c.RightExpr = copyExpression(n.RightExpr)
+		return &c
+	case *InfixExpression:
+		c := *n
+		c.LeftExpr = copyExpression(n.LeftExpr)
+		c.RightExpr = copyExpression(n.RightExpr)
+		return &c
+	case *AssignExpression:
+		c := *n
+		c.Target = copyExpression(n.Target)
+		c.Value = copyExpression(n.Value)
+		return &c
+	case *IfExpression:
+		c := *n
+		c.Condition = copyExpression(n.Condition)
+		c.Consequence = copyBlock(n.Consequence)
+		c.Alternative = copyBlock(n.Alternative)
+		return &c
+	case *MatchExpression:
+		c := *n
+		c.Subject = copyExpression(n.Subject)
+		c.Arms = make([]*MatchArm, len(n.Arms))
+		for i, arm := range n.Arms {
+			c.Arms[i] = &MatchArm{
+				Patterns: copyExpressions(arm.Patterns),
+				Guard:    copyExpression(arm.Guard),
+				Body:     copyBlock(arm.Body),
+			}
+		}
+		return &c
+	case *FunctionLiteral:
+		return copyFunction(n)
+	case *MacroLiteral:
+		c := *n
+		c.Parameters = copyIdentifiers(n.Parameters)
+		c.Body = copyBlock(n.Body)
+		return &c
+	case *CallExpression:
+		c := *n
+		c.Function = copyExpression(n.Function)
+		c.Arguments = copyExpressions(n.Arguments)
+		return &c
+	case *SpreadExpression:
+		c := *n
+		c.Value = copyExpression(n.Value)
+		return &c
+	case *ArrayLiteral:
+		c := *n
+		c.Elements = copyExpressions(n.Elements)
+		return &c
+	case *IndexExpression:
+		c := *n
+		c.Left = copyExpression(n.Left)
+		c.Index = copyExpression(n.Index)
+		return &c
+	case *HashLiteral:
+		c := *n
+		c.Pairs = make([]HashPair, len(n.Pairs))
+		for i, pair := range n.Pairs {
+			c.Pairs[i] = HashPair{Key: copyExpression(pair.Key), Value: copyExpression(pair.Value)}
+		}
+		return &c
+
+	case *ArrayPattern:
+		c := *n
+		c.Elements = make([]Pattern, len(n.Elements))
+		for i, element := range n.Elements {
+			c.Elements[i] = Copy(element).(Pattern)
+		}
+		c.Rest = copyIdentifier(n.Rest)
+		return &c
+	case *HashPattern:
+		c := *n
+		c.Keys = copyIdentifiers(n.Keys)
+		return &c
+
+	default:
+		panic(fmt.Sprintf("ast.Copy: unexpected node type %T", n))
+	}
+}
+
+func copyStatements(list []Statement) []Statement {
+	if list == nil {
+		return nil
+	}
+	c := make([]Statement, len(list))
+	for i, stmt := range list {
+		c[i] = Copy(stmt).(Statement)
+	}
+	return c
+}
+
+func copyExpressions(list []Expression) []Expression {
+	if list == nil {
+		return nil
+	}
+	c := make([]Expression, len(list))
+	for i, expr := range list {
+		c[i] = copyExpression(expr)
+	}
+	return c
+}
+
+func copyExpression(expr Expression) Expression {
+	if expr == nil {
+		return nil
+	}
+	return Copy(expr).(Expression)
+}
+
+func copyIdentifiers(list []*Identifier) []*Identifier {
+	if list == nil {
+		return nil
+	}
+	c := make([]*Identifier, len(list))
+	for i, ident := range list {
+		c[i] = copyIdentifier(ident)
+	}
+	return c
+}
+
+func copyIdentifier(ident *Identifier) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	c := *ident
+	return &c
+}
+
+func copyBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	c := *block
+	c.Statements = copyStatements(block.Statements)
+	return &c
+}
+
+func copyFunction(fn *FunctionLiteral) *FunctionLiteral {
+	if fn == nil {
+		return nil
+	}
+	c := *fn
+	c.Name = copyIdentifier(fn.Name)
+	if fn.Parameters != nil {
+		c.Parameters = make([]Pattern, len(fn.Parameters))
+		for i, param := range fn.Parameters {
+			c.Parameters[i] = Copy(param).(Pattern)
+		}
+	}
+	c.Defaults = copyExpressions(fn.Defaults)
+	c.Rest = copyIdentifier(fn.Rest)
+	c.Body = copyBlock(fn.Body)
+	return &c
+}
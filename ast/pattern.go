@@ -0,0 +1,87 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// Pattern the target of a binding, an Identifier or a destructuring pattern, e.g. [a, ...rest] or {name, age}
+type Pattern interface {
+	Expression
+	patternNode()
+}
+
+// ArrayPattern [a, [b, c], ...rest] binds the elements of an array, Rest takes the remaining elements
+type ArrayPattern struct {
+	Token    token.Token // '[' lexical unit
+	Elements []Pattern
+	Rest     *Identifier
+	RBracket token.Token // ']' lexical unit
+}
+
+func (a *ArrayPattern) expressionNode()      {}
+func (a *ArrayPattern) patternNode()         {}
+func (a *ArrayPattern) TokenLiteral() string { return a.Token.Literal }
+func (a *ArrayPattern) Pos() token.Position  { return a.Token.Pos }
+func (a *ArrayPattern) End() token.Position {
+	if a.RBracket.End.IsValid() {
+		return a.RBracket.End
+	}
+	return a.Token.End
+}
+func (a *ArrayPattern) PrintNode() string {
+	var elements []string
+	for _, e := range a.Elements {
+		elements = append(elements, e.PrintNode())
+	}
+	if a.Rest != nil {
+		elements = append(elements, "..."+a.Rest.PrintNode())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern {name, age} binds the values which a hash holds under the string keys "name" and "age"
+type HashPattern struct {
+	Token  token.Token // '{' lexical unit
+	Keys   []*Identifier
+	RBrace token.Token // '}' lexical unit
+}
+
+func (h *HashPattern) expressionNode()      {}
+func (h *HashPattern) patternNode()         {}
+func (h *HashPattern) TokenLiteral() string { return h.Token.Literal }
+func (h *HashPattern) Pos() token.Position  { return h.Token.Pos }
+func (h *HashPattern) End() token.Position {
+	if h.RBrace.End.IsValid() {
+		return h.RBrace.End
+	}
+	return h.Token.End
+}
+func (h *HashPattern) PrintNode() string {
+	var keys []string
+	for _, key := range h.Keys {
+		keys = append(keys, key.PrintNode())
+	}
+	return "{" + strings.Join(keys, ", ") + "}"
+}
+
+// PatternNames return the identifiers bound by the pattern from left to right
+func PatternNames(pattern Pattern) []*Identifier {
+	switch p := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{p}
+	case *ArrayPattern:
+		var names []*Identifier
+		for _, e := range p.Elements {
+			names = append(names, PatternNames(e)...)
+		}
+		if p.Rest != nil {
+			names = append(names, p.Rest)
+		}
+		return names
+	case *HashPattern:
+		return append([]*Identifier(nil), p.Keys...)
+	}
+	return nil
+}
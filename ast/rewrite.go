@@ -0,0 +1,145 @@
+package ast
+
+import "fmt"
+
+// Rewrite apply f to the children of the node depth-first and then to the node itself, it returns what f returns for node.
+// every child is replaced by the result of f, which must be usable in the place of the child, e.g. a *BlockStatement
+// for the body of a function or an *Identifier for a parameter name, otherwise Rewrite panics. the nil children are skipped
+func Rewrite(node Node, f func(Node) Node) Node {
+	switch n := node.(type) {
+	case *Program:
+		rewriteStatements(n.Statements, f)
+	case *BlockStatement:
+		rewriteStatements(n.Statements, f)
+	case *LetStatement:
+		if n.Name != nil {
+			n.Name = rewriteIdentifier(n.Name, f)
+		}
+		if n.Pattern != nil {
+			n.Pattern = rewriteAs[Pattern](n.Pattern, f)
+		}
+		n.Value = rewriteExpression(n.Value, f)
+	case *ReturnStatement:
+		n.ReturnValue = rewriteExpression(n.ReturnValue, f)
+	case *ExpressionStatement:
+		n.Expression = rewriteExpression(n.Expression, f)
+	case *WhileStatement:
+		n.Condition = rewriteExpression(n.Condition, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *ForStatement:
+		n.Variable = rewriteIdentifier(n.Variable, f)
+		n.Iterable = rewriteExpression(n.Iterable, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *FunctionStatement:
+		n.Function = rewriteAs[*FunctionLiteral](n.Function, f)
+	case *BreakStatement, *ContinueStatement:
+		// nothing to do
+
+	case *Identifier, *Integer, *FloatLiteral, *StringLiteral, *Boolean, *Comment:
+		// nothing to do
+
+	case *PrefixExpression:
+		n.RightExpr = rewriteExpression(n.RightExpr, f)
+	case *InfixExpression:
+		n.LeftExpr = rewriteExpression(n.LeftExpr, f)
+		n.RightExpr = rewriteExpression(n.RightExpr, f)
+	case *AssignExpression:
+		n.Target = rewriteExpression(n.Target, f)
+		n.Value = rewriteExpression(n.Value, f)
+	case *IfExpression:
+		n.Condition = rewriteExpression(n.Condition, f)
+		n.Consequence = rewriteBlock(n.Consequence, f)
+		n.Alternative = rewriteBlock(n.Alternative, f)
+	case *MatchExpression:
+		n.Subject = rewriteExpression(n.Subject, f)
+		for _, arm := range n.Arms {
+			rewriteExpressions(arm.Patterns, f)
+			arm.Guard = rewriteExpression(arm.Guard, f)
+			arm.Body = rewriteBlock(arm.Body, f)
+		}
+	case *FunctionLiteral:
+		n.Name = rewriteIdentifier(n.Name, f)
+		for i, param := range n.Parameters {
+			n.Parameters[i] = rewriteAs[Pattern](param, f)
+		}
+		rewriteExpressions(n.Defaults, f)
+		n.Rest = rewriteIdentifier(n.Rest, f)
+		n.Body = rewriteBlock(n.Body, f)
+	case *MacroLiteral:
+		for i, param := range n.Parameters {
+			n.Parameters[i] = rewriteIdentifier(param, f)
+		}
+		n.Body = rewriteBlock(n.Body, f)
+	case *CallExpression:
+		n.Function = rewriteExpression(n.Function, f)
+		rewriteExpressions(n.Arguments, f)
+	case *SpreadExpression:
+		n.Value = rewriteExpression(n.Value, f)
+	case *ArrayLiteral:
+		rewriteExpressions(n.Elements, f)
+	case *IndexExpression:
+		n.Left = rewriteExpression(n.Left, f)
+		n.Index = rewriteExpression(n.Index, f)
+	case *HashLiteral:
+		for i, pair := range n.Pairs {
+			n.Pairs[i] = HashPair{Key: rewriteExpression(pair.Key, f), Value: rewriteExpression(pair.Value, f)}
+		}
+
+	case *ArrayPattern:
+		for i, element := range n.Elements {
+			n.Elements[i] = rewriteAs[Pattern](element, f)
+		}
+		n.Rest = rewriteIdentifier(n.Rest, f)
+	case *HashPattern:
+		for i, key := range n.Keys {
+			n.Keys[i] = rewriteIdentifier(key, f)
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+	return f(node)
+}
+
+// rewriteAs rewrite the child node, the replacement must be a T
+func rewriteAs[T Node](node T, f func(Node) Node) T {
+	replaced := Rewrite(node, f)
+	result, ok := replaced.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T by %T", node, replaced))
+	}
+	return result
+}
+
+func rewriteStatements(list []Statement, f func(Node) Node) {
+	for i, stmt := range list {
+		list[i] = rewriteAs[Statement](stmt, f)
+	}
+}
+
+func rewriteExpressions(list []Expression, f func(Node) Node) {
+	for i, expr := range list {
+		list[i] = rewriteExpression(expr, f)
+	}
+}
+
+func rewriteExpression(expr Expression, f func(Node) Node) Expression {
+	if expr == nil {
+		return nil
+	}
+	return rewriteAs[Expression](expr, f)
+}
+
+func rewriteIdentifier(ident *Identifier, f func(Node) Node) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	return rewriteAs[*Identifier](ident, f)
+}
+
+func rewriteBlock(block *BlockStatement, f func(Node) Node) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	return rewriteAs[*BlockStatement](block, f)
+}
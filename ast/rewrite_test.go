@@ -0,0 +1,192 @@
+package ast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+func TestRewrite(t *testing.T) {
+	one := func() Expression { return &Integer{Token: token.New(token.INT, "1"), Value: 1} }
+	two := func() Expression { return &Integer{Token: token.New(token.INT, "2"), Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*Integer)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		return two()
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&InfixExpression{LeftExpr: one(), Operator: "+", RightExpr: two()},
+			&InfixExpression{LeftExpr: two(), Operator: "+", RightExpr: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", RightExpr: one()},
+			&PrefixExpression{Operator: "-", RightExpr: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&IfExpression{
+				Condition:   one(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&IfExpression{
+				Condition:   two(),
+				Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+				Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{&ReturnStatement{ReturnValue: one()}, &ReturnStatement{ReturnValue: two()}},
+		{&LetStatement{Value: one()}, &LetStatement{Value: two()}},
+		{
+			&FunctionLiteral{
+				Parameters: []Pattern{},
+				Defaults:   []Expression{one()},
+				Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			},
+			&FunctionLiteral{
+				Parameters: []Pattern{},
+				Defaults:   []Expression{two()},
+				Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+			},
+		},
+		{
+			&CallExpression{Function: one(), Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: two(), Arguments: []Expression{two(), two()}},
+		},
+		{&ArrayLiteral{Elements: []Expression{one(), one()}}, &ArrayLiteral{Elements: []Expression{two(), two()}}},
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}}},
+			&HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}}},
+		},
+		{
+			&WhileStatement{Condition: one(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			&WhileStatement{Condition: two(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{
+			&ForStatement{Variable: &Identifier{Value: "x"}, Iterable: one(), Body: &BlockStatement{}},
+			&ForStatement{Variable: &Identifier{Value: "x"}, Iterable: two(), Body: &BlockStatement{}},
+		},
+		{
+			&AssignExpression{Target: &IndexExpression{Left: one(), Index: one()}, Operator: "=", Value: one()},
+			&AssignExpression{Target: &IndexExpression{Left: two(), Index: two()}, Operator: "=", Value: two()},
+		},
+		{
+			&MatchExpression{Subject: one(), Arms: []*MatchArm{
+				{Patterns: []Expression{one()}, Guard: one(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			}},
+			&MatchExpression{Subject: two(), Arms: []*MatchArm{
+				{Patterns: []Expression{two()}, Guard: two(), Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+			}},
+		},
+		{
+			&FunctionStatement{Function: &FunctionLiteral{Name: &Identifier{Value: "f"}, Body: &BlockStatement{Statements: []Statement{&ReturnStatement{ReturnValue: one()}}}}},
+			&FunctionStatement{Function: &FunctionLiteral{Name: &Identifier{Value: "f"}, Body: &BlockStatement{Statements: []Statement{&ReturnStatement{ReturnValue: two()}}}}},
+		},
+		{
+			&MacroLiteral{Parameters: []*Identifier{{Value: "a"}}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: one()}}}},
+			&MacroLiteral{Parameters: []*Identifier{{Value: "a"}}, Body: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: two()}}}},
+		},
+		{
+			&ArrayLiteral{Elements: []Expression{&SpreadExpression{Value: one()}}},
+			&ArrayLiteral{Elements: []Expression{&SpreadExpression{Value: two()}}},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Rewrite(tt.input, turnOneIntoTwo)
+		if !reflect.DeepEqual(modified, tt.expected) {
+			t.Errorf("not equal. got=%#v, want=%#v", modified, tt.expected)
+		}
+	}
+}
+
+func TestRewriteIdentifiers(t *testing.T) {
+	input := &LetStatement{
+		Token:   token.New(token.LET, "let"),
+		Pattern: &ArrayPattern{Elements: []Pattern{&Identifier{Value: "a"}, &HashPattern{Keys: []*Identifier{{Value: "b"}}}}, Rest: &Identifier{Value: "c"}},
+		Value: &FunctionLiteral{
+			Token:      token.New(token.FUNCTION, "fn"),
+			Name:       &Identifier{Value: "d"},
+			Parameters: []Pattern{&Identifier{Value: "e"}},
+			Defaults:   []Expression{&Identifier{Value: "f"}},
+			Rest:       &Identifier{Value: "g"},
+			Body:       &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: &Identifier{Value: "h"}}}},
+		},
+	}
+
+	var renamed []string
+	Rewrite(input, func(node Node) Node {
+		if ident, ok := node.(*Identifier); ok {
+			renamed = append(renamed, ident.Value)
+			return &Identifier{Value: ident.Value + "_"}
+		}
+		return node
+	})
+
+	if actual := input.PrintNode(); actual != "let [a_, {b_}, ...c_] = fn d_(e_ = f_, ...g_) h_;" {
+		t.Errorf("identifiers not rewritten. got=%q", actual)
+	}
+	if strings.Join(renamed, "") != "abcdefgh" {
+		t.Errorf("identifiers rewritten in the wrong order. got=%q", renamed)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("replacing a block by an integer didn't panic")
+		}
+	}()
+	Rewrite(&WhileStatement{Condition: &Boolean{Value: true}, Body: &BlockStatement{}}, func(node Node) Node {
+		if _, ok := node.(*BlockStatement); ok {
+			return &Integer{Value: 1}
+		}
+		return node
+	})
+}
+
+func TestCopy(t *testing.T) {
+	body := &BlockStatement{Statements: []Statement{
+		&ExpressionStatement{Expression: &InfixExpression{LeftExpr: &Identifier{Value: "a"}, Operator: "+", RightExpr: &Integer{Value: 1}}},
+	}}
+	original := &FunctionLiteral{
+		Parameters: []Pattern{&Identifier{Value: "a"}, &ArrayPattern{Elements: []Pattern{&Identifier{Value: "b"}}}},
+		Defaults:   []Expression{nil, &ArrayLiteral{Elements: []Expression{&Integer{Value: 2}}}},
+		Body:       body,
+	}
+
+	copied := Copy(original)
+	if !reflect.DeepEqual(copied, original) {
+		t.Fatalf("copy not equal. got=%#v, want=%#v", copied, original)
+	}
+
+	// rewriting the copy leaves the original untouched
+	Rewrite(copied, func(node Node) Node {
+		if integer, ok := node.(*Integer); ok {
+			return &Integer{Value: integer.Value * 10}
+		}
+		return node
+	})
+	if integer := body.Statements[0].(*ExpressionStatement).Expression.(*InfixExpression).RightExpr.(*Integer); integer.Value != 1 {
+		t.Errorf("original body changed. got=%d", integer.Value)
+	}
+	if integer := original.Defaults[1].(*ArrayLiteral).Elements[0].(*Integer); integer.Value != 2 {
+		t.Errorf("original default changed. got=%d", integer.Value)
+	}
+}
@@ -0,0 +1,154 @@
+package ast
+
+import "fmt"
+
+// Visitor the Visit method is invoked for each node encountered by Walk.
+// if the result visitor w is not nil, Walk visits each of the children of node with w, followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverse the tree in depth-first order, the children are visited in source order.
+// it starts by calling v.Visit(node), node must not be nil
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		// the comments aren't children of the program, they are attached to no node
+		walkStatements(v, n.Statements)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *LetStatement:
+		Walk(v, n.Target())
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+	case *ReturnStatement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+	case *WhileStatement:
+		Walk(v, n.Condition)
+		Walk(v, n.Body)
+	case *ForStatement:
+		Walk(v, n.Variable)
+		Walk(v, n.Iterable)
+		Walk(v, n.Body)
+	case *FunctionStatement:
+		Walk(v, n.Function)
+	case *BreakStatement, *ContinueStatement:
+		// nothing to do
+
+	case *Identifier, *Integer, *FloatLiteral, *StringLiteral, *Boolean, *Comment:
+		// nothing to do
+
+	case *PrefixExpression:
+		Walk(v, n.RightExpr)
+	case *InfixExpression:
+		Walk(v, n.LeftExpr)
+		Walk(v, n.RightExpr)
+	case *AssignExpression:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+	case *IfExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+	case *MatchExpression:
+		Walk(v, n.Subject)
+		for _, arm := range n.Arms {
+			walkExpressions(v, arm.Patterns)
+			if arm.Guard != nil {
+				Walk(v, arm.Guard)
+			}
+			Walk(v, arm.Body)
+		}
+	case *FunctionLiteral:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		for i, param := range n.Parameters {
+			Walk(v, param)
+			if i < len(n.Defaults) && n.Defaults[i] != nil {
+				Walk(v, n.Defaults[i])
+			}
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+		Walk(v, n.Body)
+	case *MacroLiteral:
+		for _, param := range n.Parameters {
+			Walk(v, param)
+		}
+		Walk(v, n.Body)
+	case *CallExpression:
+		Walk(v, n.Function)
+		walkExpressions(v, n.Arguments)
+	case *SpreadExpression:
+		Walk(v, n.Value)
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			Walk(v, pair.Key)
+			Walk(v, pair.Value)
+		}
+
+	case *ArrayPattern:
+		for _, element := range n.Elements {
+			Walk(v, element)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *HashPattern:
+		for _, key := range n.Keys {
+			Walk(v, key)
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		Walk(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, expr := range list {
+		Walk(v, expr)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverse the tree in depth-first order like Walk, it starts by calling f(node).
+// if f returns true, Inspect invokes f recursively for each of the children of node, followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
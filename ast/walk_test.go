@@ -0,0 +1,109 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+func TestInspect(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.New(token.IDENTIFIER, name), Value: name}
+	}
+	integer := func(value int64) *Integer {
+		return &Integer{Token: token.New(token.INT, fmt.Sprint(value)), Value: value}
+	}
+
+	// let add = fn(a, b = 1) { while (a) { a -= b } match a { 0 if b => { [a, ...b] }, _ => { {a: b}[a] } } }; add(2)
+	program := &Program{Statements: []Statement{
+		&LetStatement{Name: ident("add"), Value: &FunctionLiteral{
+			Parameters: []Pattern{ident("a"), ident("b")},
+			Defaults:   []Expression{nil, integer(1)},
+			Body: &BlockStatement{Statements: []Statement{
+				&WhileStatement{Condition: ident("a"), Body: &BlockStatement{Statements: []Statement{
+					&ExpressionStatement{Expression: &AssignExpression{Target: ident("a"), Operator: "-=", Value: ident("b")}},
+				}}},
+				&ExpressionStatement{Expression: &MatchExpression{Subject: ident("a"), Arms: []*MatchArm{
+					{Patterns: []Expression{integer(0)}, Guard: ident("b"), Body: &BlockStatement{Statements: []Statement{
+						&ExpressionStatement{Expression: &ArrayLiteral{Elements: []Expression{ident("a"), &SpreadExpression{Value: ident("b")}}}},
+					}}},
+					{Patterns: []Expression{ident(Wildcard)}, Body: &BlockStatement{Statements: []Statement{
+						&ExpressionStatement{Expression: &IndexExpression{
+							Left:  &HashLiteral{Pairs: []HashPair{{Key: ident("a"), Value: ident("b")}}},
+							Index: ident("a"),
+						}},
+					}}},
+				}}},
+			}},
+		}},
+		&ExpressionStatement{Expression: &CallExpression{Function: ident("add"), Arguments: []Expression{integer(2)}}},
+	}}
+
+	var visited []string
+	Inspect(program, func(node Node) bool {
+		switch n := node.(type) {
+		case *Identifier:
+			visited = append(visited, n.Value)
+		case *Integer:
+			visited = append(visited, n.TokenLiteral())
+		}
+		return true
+	})
+
+	expected := []string{"add", "a", "b", "1", "a", "a", "b", "a", "0", "b", "a", "b", "_", "a", "b", "a", "add", "2"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong visit order. want=%q, got=%q", expected, visited)
+	}
+
+	// returning false skips the children of the node
+	var names []string
+	Inspect(program, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			names = append(names, ident.Value)
+		}
+		_, isFunction := node.(*FunctionLiteral)
+		return !isFunction
+	})
+	if !reflect.DeepEqual(names, []string{"add", "add"}) {
+		t.Errorf("function literal not skipped. got=%q", names)
+	}
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+	nils     *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		*v.nils++
+		return nil
+	}
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth, nils: v.nils}
+}
+
+func TestWalk(t *testing.T) {
+	// 1 + -x
+	node := &InfixExpression{
+		LeftExpr:  &Integer{Value: 1},
+		Operator:  "+",
+		RightExpr: &PrefixExpression{Operator: "-", RightExpr: &Identifier{Value: "x"}},
+	}
+
+	var maxDepth, nils int
+	Walk(depthVisitor{maxDepth: &maxDepth, nils: &nils}, node)
+
+	if maxDepth != 2 {
+		t.Errorf("wrong depth. want=2, got=%d", maxDepth)
+	}
+	// every node is followed by a Visit(nil) once its children are walked
+	if nils != 4 {
+		t.Errorf("wrong number of Visit(nil). want=4, got=%d", nils)
+	}
+}
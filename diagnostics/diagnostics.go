@@ -0,0 +1,200 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// Severity how serious a diagnostic is
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic a message about a span of the source text
+type Diagnostic struct {
+	Severity Severity
+	Code     string         // stable error code, may be empty
+	Message  string         // the headline of the report
+	Pos      token.Position // start of the span, the snippet is omitted if it is invalid
+	End      token.Position // position immediately after the span
+	Notes    []string       // extra context, rendered as "= note: ..."
+	Hints    []string       // suggestions, rendered as "= help: ..."
+}
+
+// ANSI escape sequences used when colors are enabled
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[1;31m"
+	colorYellow  = "\x1b[1;33m"
+	colorBlue    = "\x1b[1;34m"
+	colorMagenta = "\x1b[1;35m"
+)
+
+// Renderer render diagnostics about one source text in a rustc-like layout:
+//
+//	error[P0001]: expected next token type to be ), got ; instead
+//	 --> main.mk:1:17
+//	  |
+//	1 | let a = add(1, 2;
+//	  |                 ^
+//	  = help: ...
+type Renderer struct {
+	Filename string // the file name of the source, if set, spans in other files are rendered without a snippet
+	Source   string // the source text the positions refer to
+	Color    bool   // use ANSI colors, disable it for CI logs and files
+}
+
+func NewRenderer(source string, color bool) *Renderer {
+	return &Renderer{Source: source, Color: color}
+}
+
+// NewFileRenderer create a renderer for the source text of the named file
+func NewFileRenderer(filename string, source string, color bool) *Renderer {
+	return &Renderer{Filename: filename, Source: source, Color: color}
+}
+
+// Render write the report of the diagnostic to w
+func (r *Renderer) Render(w io.Writer, d Diagnostic) error {
+	_, err := io.WriteString(w, r.Sprint(d))
+	return err
+}
+
+// Sprint return the report of the diagnostic, ending with a newline
+func (r *Renderer) Sprint(d Diagnostic) string {
+	var out strings.Builder
+
+	// header: error[CODE]: message
+	severityColor := colorRed
+	if d.Severity == Warning {
+		severityColor = colorYellow
+	}
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	out.WriteString(r.paint(severityColor, header))
+	out.WriteString(r.paint(colorBold, ": "+d.Message))
+	out.WriteString("\n")
+
+	if !d.Pos.IsValid() {
+		r.writeTrailers(&out, "", d)
+		return out.String()
+	}
+
+	line, ok := r.line(d.Pos.Line)
+	if r.Filename != "" && d.Pos.Filename != r.Filename {
+		ok = false
+	}
+	lineNumber := fmt.Sprintf("%d", d.Pos.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+
+	// location: --> file:line:col
+	out.WriteString(gutter + r.paint(colorBlue, "--> ") + d.Pos.String() + "\n")
+	if !ok {
+		// the span lies in another source, there is no snippet to show
+		r.writeTrailers(&out, gutter, d)
+		return out.String()
+	}
+	out.WriteString(gutter + r.paint(colorBlue, " |") + "\n")
+
+	// the offending line and the underline
+	out.WriteString(r.paint(colorBlue, lineNumber+" | ") + line + "\n")
+	out.WriteString(gutter + r.paint(colorBlue, " | ") + underlinePadding(line, d.Pos.Column))
+	out.WriteString(r.paint(severityColor, underline(line, d.Pos, d.End)) + "\n")
+
+	r.writeTrailers(&out, gutter, d)
+	return out.String()
+}
+
+// writeTrailers write the notes and the hints below the snippet
+func (r *Renderer) writeTrailers(out *strings.Builder, gutter string, d Diagnostic) {
+	for _, note := range d.Notes {
+		out.WriteString(gutter + r.paint(colorBlue, " = ") + r.paint(colorBold, "note") + ": " + note + "\n")
+	}
+	for _, hint := range d.Hints {
+		out.WriteString(gutter + r.paint(colorBlue, " = ") + r.paint(colorMagenta, "help") + ": " + hint + "\n")
+	}
+}
+
+// line return the text of the line with the given number, starting at 1
+func (r *Renderer) line(number int) (string, bool) {
+	lines := strings.Split(r.Source, "\n")
+	if number < 1 || number > len(lines) {
+		return "", false
+	}
+	return strings.TrimSuffix(lines[number-1], "\r"), true
+}
+
+func (r *Renderer) paint(color string, text string) string {
+	if !r.Color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// underlinePadding the whitespace placing the underline below the column, tabs are kept so the alignment matches
+func underlinePadding(line string, column int) string {
+	var padding strings.Builder
+	prefix := line
+	if column-1 < len(line) {
+		prefix = line[:column-1]
+	}
+	for _, ch := range prefix {
+		if ch == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+	return padding.String()
+}
+
+// underline the ^~~~ marker of the span, a span reaching past the line is cut at the end of the line
+func underline(line string, pos token.Position, end token.Position) string {
+	start := pos.Column - 1
+	stop := len(line)
+	if end.IsValid() && end.Line == pos.Line && end.Column-1 < stop {
+		stop = end.Column - 1
+	}
+	width := 1
+	if start < stop {
+		width = utf8.RuneCountInString(line[start:stop])
+	}
+	if width < 1 {
+		width = 1
+	}
+	return "^" + strings.Repeat("~", width-1)
+}
+
+// ColorEnabled report whether reports written to w should be colored:
+// only terminals are colored and the NO_COLOR environment variable turns colors off
+func ColorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,123 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/GzzyZm/interpreter/lexer"
+	"github.com/GzzyZm/interpreter/object"
+	"github.com/GzzyZm/interpreter/parser"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+func TestRender(t *testing.T) {
+	source := "let x = 1;\n\tlet y = foo + 1;\n"
+
+	tests := []struct {
+		diagnostic Diagnostic
+		expected   string
+	}{
+		{
+			Diagnostic{
+				Code:    "E0001",
+				Message: "identifier not found: foo",
+				Pos:     token.Position{Filename: "main.mk", Line: 2, Column: 10},
+				End:     token.Position{Filename: "main.mk", Line: 2, Column: 13},
+				Notes:   []string{"identifiers must be bound before use"},
+				Hints:   []string{"did you mean `x`?"},
+			},
+			"error[E0001]: identifier not found: foo\n" +
+				" --> main.mk:2:10\n" +
+				"  |\n" +
+				"2 | \tlet y = foo + 1;\n" +
+				"  | \t        ^~~\n" +
+				"  = note: identifiers must be bound before use\n" +
+				"  = help: did you mean `x`?\n",
+		},
+		{
+			Diagnostic{
+				Severity: Warning,
+				Message:  "unused value",
+				Pos:      token.Position{Line: 1, Column: 11},
+			},
+			"warning: unused value\n" +
+				" --> 1:11\n" +
+				"  |\n" +
+				"1 | let x = 1;\n" +
+				"  |           ^\n",
+		},
+		{
+			Diagnostic{Message: "division by zero", Notes: []string{"no position"}},
+			"error: division by zero\n" +
+				" = note: no position\n",
+		},
+	}
+
+	r := NewRenderer(source, false)
+	for i, tt := range tests {
+		if actual := r.Sprint(tt.diagnostic); actual != tt.expected {
+			t.Errorf("tests[%d] - report wrong.\nwant:\n%s\ngot:\n%s", i, tt.expected, actual)
+		}
+	}
+}
+
+func TestRenderColor(t *testing.T) {
+	r := NewRenderer("1 +", true)
+	actual := r.Sprint(Diagnostic{Message: "oops", Pos: token.Position{Line: 1, Column: 3}})
+	expected := "\x1b[1;31merror\x1b[0m\x1b[1m: oops\x1b[0m\n" +
+		" \x1b[1;34m--> \x1b[0m1:3\n" +
+		" \x1b[1;34m |\x1b[0m\n" +
+		"\x1b[1;34m1 | \x1b[0m1 +\n" +
+		" \x1b[1;34m | \x1b[0m  \x1b[1;31m^\x1b[0m\n"
+	if actual != expected {
+		t.Errorf("colored report wrong.\nwant:%q\ngot: %q", expected, actual)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	err := &object.Error{
+		Message: "division by zero",
+		Pos:     token.Position{Filename: "lib.mk", Line: 1, Column: 25},
+		End:     token.Position{Filename: "lib.mk", Line: 1, Column: 30},
+		Stack: []object.Frame{
+			{Function: "divide", Pos: token.Position{Filename: "main.mk", Line: 1, Column: 17}},
+			{Function: "<anonymous>", Pos: token.Position{Filename: "main.mk", Line: 2, Column: 1}},
+		},
+	}
+
+	expected := "error: division by zero\n" +
+		" --> lib.mk:1:25\n" +
+		"  = note: in divide called at main.mk:1:17\n" +
+		"  = note: in <anonymous> called at main.mk:2:1\n"
+	actual := NewFileRenderer("main.mk", "let f = fn(x) { divide(x, 0) };\nf(1)", false).Sprint(FromError(err))
+	if actual != expected {
+		t.Errorf("report wrong.\nwant:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestFromParseError(t *testing.T) {
+	source := "let a = add(1, 2;"
+	p := parser.New(lexer.New(source))
+	p.ParseProgram()
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 parser error. got=%q", p.Errors())
+	}
+
+	expected := "error[P0001]: expected next token type to be ), got ; instead\n" +
+		" --> 1:17\n" +
+		"  |\n" +
+		"1 | let a = add(1, 2;\n" +
+		"  |                 ^\n" +
+		"  = help: add the missing `)`\n"
+	actual := NewRenderer(source, false).Sprint(FromParseError(p.Errors()[0]))
+	if actual != expected {
+		t.Errorf("report wrong.\nwant:\n%s\ngot:\n%s", expected, actual)
+	}
+	p = parser.New(lexer.New(`{"a": 1;`))
+	p.ParseProgram()
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 parser error. got=%q", p.Errors())
+	}
+	if hints := FromParseError(p.Errors()[0]).Hints; len(hints) != 1 || hints[0] != "add the missing `}`" {
+		t.Errorf("hash literal hint wrong. got=%q", hints)
+	}
+}
@@ -0,0 +1,19 @@
+package diagnostics
+
+import (
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// FromError convert a runtime error to a diagnostic, every frame of its call stack becomes a note
+func FromError(err *object.Error) Diagnostic {
+	d := Diagnostic{
+		Severity: Error,
+		Message:  err.Message,
+		Pos:      err.Pos,
+		End:      err.End,
+	}
+	for _, frame := range err.Stack {
+		d.Notes = append(d.Notes, "in "+frame.String())
+	}
+	return d
+}
@@ -0,0 +1,27 @@
+package diagnostics
+
+import (
+	"github.com/GzzyZm/interpreter/parser"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// FromParseError convert a parse error to a diagnostic
+func FromParseError(err *parser.ParseError) Diagnostic {
+	d := Diagnostic{
+		Severity: Error,
+		Code:     string(err.Code),
+		Message:  err.Msg,
+		Pos:      err.Pos,
+		End:      err.End,
+	}
+	switch err.Code {
+	case parser.ErrUnexpectedToken:
+		switch err.Expected {
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			d.Hints = append(d.Hints, "add the missing `"+string(err.Expected)+"`")
+		}
+	case parser.ErrParameterOrder:
+		d.Hints = append(d.Hints, "give the parameter a default value or move it before the optional ones")
+	}
+	return d
+}
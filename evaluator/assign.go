@@ -0,0 +1,98 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// evalAssignExpression update an existing binding, an array element or a hash entry and return the assigned value.
+// a compound operator like += combines the current value with the right side first
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		val := Eval(node.Value, env)
+		if isErrorObject(val) {
+			return val
+		}
+		if node.Operator != "=" {
+			current := evalIdentifier(target, env)
+			if isErrorObject(current) {
+				return current
+			}
+			if val = evalCompoundOperator(node.Operator, current, val); isErrorObject(val) {
+				return val
+			}
+		}
+		if env.IsConst(target.Value) {
+			return newError(fmt.Sprintf("cannot assign to constant %s", target.Value))
+		}
+		if !env.Assign(target.Value, val) {
+			return newError(fmt.Sprintf("assignment to undeclared identifier: %s", target.Value))
+		}
+		return val
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isErrorObject(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isErrorObject(index) {
+			return index
+		}
+		val := Eval(node.Value, env)
+		if isErrorObject(val) {
+			return val
+		}
+		if node.Operator != "=" {
+			current := evalIndexExpression(left, index)
+			if isErrorObject(current) {
+				return current
+			}
+			if val = evalCompoundOperator(node.Operator, current, val); isErrorObject(val) {
+				return val
+			}
+		}
+		return evalIndexAssignment(left, index, val)
+	default:
+		return newError(fmt.Sprintf("cannot assign to %s", node.Target.PrintNode()))
+	}
+}
+
+// evalCompoundOperator apply the arithmetic operator of +=, -=, *= or /=
+func evalCompoundOperator(op string, current object.Object, val object.Object) object.Object {
+	return evalInfixExpression(op[:len(op)-1], current, val)
+}
+
+// evalIndexAssignment store the value in the array or hash, unlike reading, writing an array out of range is an error
+func evalIndexAssignment(left object.Object, index object.Object, val object.Object) object.Object {
+	switch container := left.(type) {
+	case *object.Array:
+		integer, ok := index.(*object.Integer)
+		if !ok && index.Type() == object.IntegerObj {
+			return newError(fmt.Sprintf("index out of range: %s with length %d", index.Inspect(), len(container.Elements)))
+		}
+		if !ok {
+			return newError(fmt.Sprintf("index operator not supported: %s[%s]", left.Type(), index.Type()))
+		}
+		idx := integer.Value
+		length := int64(len(container.Elements))
+		if idx < 0 {
+			idx += length
+		}
+		if idx < 0 || idx >= length {
+			return newError(fmt.Sprintf("index out of range: %d with length %d", integer.Value, length))
+		}
+		container.Elements[idx] = val
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError(fmt.Sprintf("unusable as hash key: %s", index.Type()))
+		}
+		container.Set(key, val)
+	default:
+		return newError(fmt.Sprintf("index operator not supported: %s[%s]", left.Type(), index.Type()))
+	}
+	return val
+}
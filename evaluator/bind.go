@@ -0,0 +1,73 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// bindPattern bind the names of the pattern to the matching parts of the value in env,
+// a value whose shape doesn't fit the pattern is an error positioned at the mismatching part of the pattern
+func bindPattern(pattern ast.Pattern, val object.Object, env *object.Environment, constant bool) *object.Error {
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		return bindName(p, val, env, constant)
+	case *ast.ArrayPattern:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return patternError(p, fmt.Sprintf("cannot destructure %s as ARRAY", val.Type()), env)
+		}
+		want, got := len(p.Elements), len(array.Elements)
+		if got < want || (got > want && p.Rest == nil) {
+			return patternError(p, fmt.Sprintf("cannot destructure array of %d elements into %d names", got, want), env)
+		}
+		for i, element := range p.Elements {
+			if err := bindPattern(element, array.Elements[i], env, constant); err != nil {
+				return err
+			}
+		}
+		if p.Rest != nil {
+			rest := append([]object.Object(nil), array.Elements[want:]...)
+			return bindName(p.Rest, &object.Array{Elements: rest}, env, constant)
+		}
+	case *ast.HashPattern:
+		hash, ok := val.(*object.Hash)
+		if !ok {
+			return patternError(p, fmt.Sprintf("cannot destructure %s as HASH", val.Type()), env)
+		}
+		for _, key := range p.Keys {
+			pair, ok := hash.Get(&object.String{Value: key.Value})
+			if !ok {
+				return patternError(key, fmt.Sprintf("key %q not found in hash", key.Value), env)
+			}
+			if err := bindName(key, pair.Value, env, constant); err != nil {
+				return err
+			}
+		}
+	default:
+		return patternError(pattern, fmt.Sprintf("cannot bind to %s", pattern.PrintNode()), env)
+	}
+	return nil
+}
+
+// bindName bind one name like a let or const statement
+func bindName(ident *ast.Identifier, val object.Object, env *object.Environment, constant bool) *object.Error {
+	if constant {
+		if !env.SetConst(ident.Value, val) {
+			if env.IsConst(ident.Value) {
+				return patternError(ident, fmt.Sprintf("cannot redeclare constant %s", ident.Value), env)
+			}
+			return patternError(ident, fmt.Sprintf("cannot redeclare %s as a constant", ident.Value), env)
+		}
+	} else if !env.Set(ident.Value, val) {
+		return patternError(ident, fmt.Sprintf("cannot redeclare constant %s", ident.Value), env)
+	}
+	return nil
+}
+
+func patternError(node ast.Node, message string, env *object.Environment) *object.Error {
+	err := newError(message)
+	err.Pos, err.End, err.Stack = node.Pos(), node.End(), currentStack(env)
+	return err
+}
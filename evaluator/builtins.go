@@ -0,0 +1,194 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// Output the writer used by the puts built-in function
+var Output io.Writer = os.Stdout
+
+// builtins the built-in functions, looked up when an identifier is not bound in the environment
+var builtins = map[string]*object.Builtin{}
+
+func init() {
+	RegisterBuiltinArity("len", 1, 1, builtinLen)
+	RegisterBuiltinArity("first", 1, 1, builtinFirst)
+	RegisterBuiltinArity("last", 1, 1, builtinLast)
+	RegisterBuiltinArity("rest", 1, 1, builtinRest)
+	RegisterBuiltinArity("push", 2, object.Variadic, builtinPush)
+	RegisterBuiltinArity("puts", 0, object.Variadic, builtinPuts)
+	RegisterBuiltinArity("type", 1, 1, builtinType)
+	RegisterBuiltinArity("int", 1, 1, builtinInt)
+	RegisterBuiltinArity("float", 1, 1, builtinFloat)
+	RegisterBuiltinArity("range", 1, 3, builtinRange)
+}
+
+// RegisterBuiltin add a built-in function or replace the existing one with the same name,
+// so programs embedding the interpreter can expose their own go functions.
+// the function accepts any number of arguments and checks them itself
+func RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	RegisterBuiltinArity(name, 0, object.Variadic, fn)
+}
+
+// RegisterBuiltinArity add a built-in function like RegisterBuiltin, the calls with less than minArgs
+// or more than maxArgs arguments fail before fn is called. maxArgs is object.Variadic for no upper limit
+func RegisterBuiltinArity(name string, minArgs int, maxArgs int, fn object.BuiltinFunction) {
+	builtins[name] = &object.Builtin{Name: name, Fn: fn, MinArgs: minArgs, MaxArgs: maxArgs}
+}
+
+func builtinLen(args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.String:
+		return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+	case *object.Array:
+		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Hash:
+		return &object.Integer{Value: int64(len(arg.Pairs))}
+	case *object.Range:
+		return &object.Integer{Value: arg.Len()}
+	default:
+		return newError(fmt.Sprintf("argument to `len` not supported, got %s", args[0].Type()))
+	}
+}
+
+func builtinFirst(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(fmt.Sprintf("argument to `first` must be ARRAY, got %s", args[0].Type()))
+	}
+	if len(array.Elements) > 0 {
+		return array.Elements[0]
+	}
+	return nullObj
+}
+
+func builtinLast(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(fmt.Sprintf("argument to `last` must be ARRAY, got %s", args[0].Type()))
+	}
+	if length := len(array.Elements); length > 0 {
+		return array.Elements[length-1]
+	}
+	return nullObj
+}
+
+// builtinRest return a new array without the first element
+func builtinRest(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(fmt.Sprintf("argument to `rest` must be ARRAY, got %s", args[0].Type()))
+	}
+	length := len(array.Elements)
+	if length == 0 {
+		return nullObj
+	}
+	elements := make([]object.Object, length-1)
+	copy(elements, array.Elements[1:])
+	return &object.Array{Elements: elements}
+}
+
+// builtinPush return a new array with the rest of the arguments appended, the original array is left untouched
+func builtinPush(args ...object.Object) object.Object {
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError(fmt.Sprintf("argument to `push` must be ARRAY, got %s", args[0].Type()))
+	}
+	elements := make([]object.Object, 0, len(array.Elements)+len(args)-1)
+	elements = append(elements, array.Elements...)
+	elements = append(elements, args[1:]...)
+	return &object.Array{Elements: elements}
+}
+
+func builtinPuts(args ...object.Object) object.Object {
+	for _, arg := range args {
+		if _, err := fmt.Fprintln(Output, arg.Inspect()); err != nil {
+			return newError(fmt.Sprintf("puts: %s", err))
+		}
+	}
+	return nullObj
+}
+
+// builtinInt convert a number or a numeric string to an integer, floats are truncated toward zero
+func builtinInt(args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInt:
+		return arg
+	case *object.Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError(fmt.Sprintf("cannot convert %s to INTEGER", arg.Inspect()))
+		}
+		value, _ := big.NewFloat(math.Trunc(arg.Value)).Int(nil)
+		return normalizeBigInt(value)
+	case *object.String:
+		value, ok := new(big.Int).SetString(arg.Value, 10)
+		if !ok {
+			return newError(fmt.Sprintf("could not parse %q as integer", arg.Value))
+		}
+		return normalizeBigInt(value)
+	default:
+		return newError(fmt.Sprintf("argument to `int` not supported, got %s", args[0].Type()))
+	}
+}
+
+// builtinFloat convert a number or a numeric string to a float
+func builtinFloat(args ...object.Object) object.Object {
+	switch arg := args[0].(type) {
+	case *object.Float:
+		return arg
+	case *object.Integer, *object.BigInt:
+		return &object.Float{Value: toFloat(arg)}
+	case *object.String:
+		value, err := strconv.ParseFloat(arg.Value, 64)
+		if err != nil {
+			return newError(fmt.Sprintf("could not parse %q as float", arg.Value))
+		}
+		return &object.Float{Value: value}
+	default:
+		return newError(fmt.Sprintf("argument to `float` not supported, got %s", args[0].Type()))
+	}
+}
+
+// builtinType return the type name of the argument, e.g. "INTEGER"
+func builtinType(args ...object.Object) object.Object {
+	return &object.String{Value: string(args[0].Type())}
+}
+
+// builtinRange create a range of integers: range(end), range(start, end) or range(start, end, step)
+func builtinRange(args ...object.Object) object.Object {
+	bounds := make([]int64, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *object.Integer:
+			bounds[i] = arg.Value
+		case *object.BigInt:
+			return newError("range bound out of int64 range")
+		default:
+			return newError(fmt.Sprintf("argument to `range` must be INTEGER, got %s", arg.Type()))
+		}
+	}
+	r := &object.Range{Step: 1}
+	switch len(bounds) {
+	case 1:
+		r.End = bounds[0]
+	case 2:
+		r.Start, r.End = bounds[0], bounds[1]
+	case 3:
+		r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
+	}
+	if r.Step == 0 {
+		return newError("range step must not be zero")
+	}
+	if r.Count() > math.MaxInt64 {
+		return newError(fmt.Sprintf("range has more than %d integers", int64(math.MaxInt64)))
+	}
+	return r
+}
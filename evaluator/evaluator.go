@@ -7,17 +7,39 @@ import (
 )
 
 var (
-	trueObj  = &object.Boolean{Value: true}
-	falseObj = &object.Boolean{Value: false}
-	nullObj  = &object.Null{}
+	trueObj     = &object.Boolean{Value: true}
+	falseObj    = &object.Boolean{Value: false}
+	nullObj     = &object.Null{}
+	breakObj    = &object.Break{}
+	continueObj = &object.Continue{}
 )
 
+// Eval evaluate the node, an error raised by the node is stamped with its position and the current call stack
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	obj := evalNode(node, env)
+	if err, ok := obj.(*object.Error); ok && !err.Pos.IsValid() && node != nil {
+		err.Pos = node.Pos()
+		err.End = node.End()
+		if err.Stack == nil {
+			err.Stack = currentStack(env)
+		}
+	}
+	return obj
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch n := node.(type) {
 	case *ast.Program:
 		return evalProgram(n, env)
 	case *ast.Integer:
+		if n.Big != nil {
+			return &object.BigInt{Value: n.Big}
+		}
 		return &object.Integer{Value: n.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: n.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: n.Value}
 	case *ast.Boolean:
 		return booleanNativeToObj(n.Value)
 	case *ast.PrefixExpression:
@@ -27,6 +49,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(n.Operator, rightExpr)
 	case *ast.InfixExpression:
+		if n.Operator == "&&" || n.Operator == "||" {
+			return evalLogicalExpression(n, env)
+		}
 		leftExpr := Eval(n.LeftExpr, env)
 		if isErrorObject(leftExpr) {
 			return leftExpr
@@ -38,6 +63,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalInfixExpression(n.Operator, leftExpr, rightExpr)
 	case *ast.IfExpression:
 		return evalIfExpression(n, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(n, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(n, env)
 	case *ast.ExpressionStatement:
 		return Eval(n.Expression, env)
 	case *ast.LetStatement:
@@ -45,7 +74,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isErrorObject(val) {
 			return val
 		}
-		env.Set(n.Name.Value, val)
+		if err := bindPattern(n.Target(), val, env, n.IsConst()); err != nil {
+			return err
+		}
 	case *ast.ReturnStatement:
 		val := Eval(n.ReturnValue, env)
 		if isErrorObject(val) {
@@ -54,17 +85,44 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Return{Value: val}
 	case *ast.BlockStatement:
 		return evalBlockStatement(n, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(n, env)
+	case *ast.ForStatement:
+		return evalForStatement(n, env)
+	case *ast.BreakStatement:
+		return breakObj
+	case *ast.ContinueStatement:
+		return continueObj
 	case *ast.Identifier:
 		return evalIdentifier(n, env)
 	case *ast.FunctionLiteral:
-		params := n.Parameters
-		body := n.Body
-		return &object.Function{
-			Parameters: params,
-			Body:       body,
-			Env:        env,
+		return newFunction(n, env)
+	case *ast.FunctionStatement:
+		// the declaration has been hoisted by the enclosing block
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(n.Elements, env)
+		if len(elements) == 1 && isErrorObject(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(n, env)
+	case *ast.IndexExpression:
+		left := Eval(n.Left, env)
+		if isErrorObject(left) {
+			return left
 		}
+		index := Eval(n.Index, env)
+		if isErrorObject(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+	case *ast.MacroLiteral:
+		return newError("macros can only be defined by top-level let statements")
 	case *ast.CallExpression:
+		if isSpecialCall(n, "quote") {
+			return evalQuote(n, env)
+		}
 		function := Eval(n.Function, env)
 		if isErrorObject(function) {
 			return function
@@ -73,12 +131,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isErrorObject(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(function, args, n, env)
 	}
 	return nil
 }
 
 func evalProgram(p *ast.Program, env *object.Environment) object.Object {
+	if err := hoistFunctions(p.Statements, env); err != nil {
+		return err
+	}
 	var obj object.Object
 	for _, stmt := range p.Statements {
 		obj = Eval(stmt, env)
@@ -93,11 +154,15 @@ func evalProgram(p *ast.Program, env *object.Environment) object.Object {
 }
 
 func evalBlockStatement(bStmt *ast.BlockStatement, env *object.Environment) object.Object {
+	if err := hoistFunctions(bStmt.Statements, env); err != nil {
+		return err
+	}
 	var obj object.Object
 	for _, stmt := range bStmt.Statements {
 		obj = Eval(stmt, env)
 		if obj != nil {
-			if objType := obj.Type(); objType == object.ReturnObj || objType == object.ErrorObj {
+			switch obj.Type() {
+			case object.ReturnObj, object.ErrorObj, object.BreakObj, object.ContinueObj:
 				return obj
 			}
 		}
@@ -105,6 +170,38 @@ func evalBlockStatement(bStmt *ast.BlockStatement, env *object.Environment) obje
 	return obj
 }
 
+// hoistFunctions bind the function declarations of a block before its first statement runs,
+// so the functions can call each other regardless of their order
+func hoistFunctions(statements []ast.Statement, env *object.Environment) *object.Error {
+	for _, stmt := range statements {
+		decl, ok := stmt.(*ast.FunctionStatement)
+		if !ok {
+			continue
+		}
+		name := decl.Function.Name
+		if !env.Set(name.Value, newFunction(decl.Function, env)) {
+			err := newError(fmt.Sprintf("cannot redeclare constant %s", name.Value))
+			err.Pos, err.End, err.Stack = name.Pos(), name.End(), currentStack(env)
+			return err
+		}
+	}
+	return nil
+}
+
+func newFunction(node *ast.FunctionLiteral, env *object.Environment) *object.Function {
+	fn := &object.Function{
+		Parameters: node.Parameters,
+		Defaults:   node.Defaults,
+		Rest:       node.Rest,
+		Body:       node.Body,
+		Env:        env,
+	}
+	if node.Name != nil {
+		fn.Name = node.Name.Value
+	}
+	return fn
+}
+
 func evalPrefixExpression(op string, expr object.Object) object.Object {
 	switch op {
 	case "!":
@@ -119,6 +216,11 @@ func evalPrefixExpression(op string, expr object.Object) object.Object {
 func evalInfixExpression(op string, lExpr object.Object, rExpr object.Object) object.Object {
 	if lExpr.Type() == object.IntegerObj && rExpr.Type() == object.IntegerObj {
 		return evalIntegerInfixExpression(op, lExpr, rExpr)
+	} else if isNumber(lExpr) && isNumber(rExpr) {
+		// one of the operands is a float, the integer one is promoted
+		return evalFloatInfixExpression(op, toFloat(lExpr), toFloat(rExpr))
+	} else if lExpr.Type() == object.StringObj && rExpr.Type() == object.StringObj {
+		return evalStringInfixExpression(op, lExpr, rExpr)
 	} else if op == "==" {
 		return booleanNativeToObj(lExpr == rExpr)
 	} else if op == "!=" {
@@ -130,6 +232,22 @@ func evalInfixExpression(op string, lExpr object.Object, rExpr object.Object) ob
 	}
 }
 
+// evalLogicalExpression the right operand of && and || is only evaluated when the left one doesn't decide the result
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	leftExpr := Eval(node.LeftExpr, env)
+	if isErrorObject(leftExpr) {
+		return leftExpr
+	}
+	if isTruth(leftExpr) == (node.Operator == "||") {
+		return booleanNativeToObj(isTruth(leftExpr))
+	}
+	rightExpr := Eval(node.RightExpr, env)
+	if isErrorObject(rightExpr) {
+		return rightExpr
+	}
+	return booleanNativeToObj(isTruth(rightExpr))
+}
+
 func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Object {
 	condObj := Eval(node.Condition, env)
 	if isErrorObject(condObj) {
@@ -155,29 +273,12 @@ func evalBangOperationExpression(expr object.Object) object.Object {
 	}
 }
 
-func evalMinusOperationExpression(expr object.Object) object.Object {
-	if expr.Type() != object.IntegerObj {
-		return newError(fmt.Sprintf("unknown operator: -%s", expr.Type()))
-	}
-	return &object.Integer{Value: -expr.(*object.Integer).Value}
-}
-
-func evalIntegerInfixExpression(op string, lExpr object.Object, rExpr object.Object) object.Object {
-	lValue := lExpr.(*object.Integer).Value
-	rValue := rExpr.(*object.Integer).Value
+func evalStringInfixExpression(op string, lExpr object.Object, rExpr object.Object) object.Object {
+	lValue := lExpr.(*object.String).Value
+	rValue := rExpr.(*object.String).Value
 	switch op {
 	case "+":
-		return &object.Integer{Value: lValue + rValue}
-	case "-":
-		return &object.Integer{Value: lValue - rValue}
-	case "*":
-		return &object.Integer{Value: lValue * rValue}
-	case "/":
-		return &object.Integer{Value: lValue / rValue}
-	case "<":
-		return booleanNativeToObj(lValue < rValue)
-	case ">":
-		return booleanNativeToObj(lValue > rValue)
+		return &object.String{Value: lValue + rValue}
 	case "==":
 		return booleanNativeToObj(lValue == rValue)
 	case "!=":
@@ -187,42 +288,235 @@ func evalIntegerInfixExpression(op string, lExpr object.Object, rExpr object.Obj
 	}
 }
 
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	val, ok := env.Get(node.Value)
+func evalIndexExpression(left object.Object, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.HashObj:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError(fmt.Sprintf("index operator not supported: %s[%s]", left.Type(), index.Type()))
+	}
+}
+
+// evalArrayIndexExpression negative index counts from the end, out of range index yields null
+func evalArrayIndexExpression(array object.Object, index object.Object) object.Object {
+	elements := array.(*object.Array).Elements
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		// an arbitrary precision index is always out of range
+		return nullObj
+	}
+	idx := integer.Value
+	length := int64(len(elements))
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 || idx >= length {
+		return nullObj
+	}
+	return elements[idx]
+}
+
+func evalHashIndexExpression(hash object.Object, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
 	if !ok {
-		return newError(fmt.Sprintf("identifier not found: %s", node.Value))
+		return newError(fmt.Sprintf("unusable as hash key: %s", index.Type()))
+	}
+	pair, ok := hash.(*object.Hash).Get(key)
+	if !ok {
+		return nullObj
 	}
-	return val
+	return pair.Value
 }
 
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isErrorObject(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError(fmt.Sprintf("unusable as hash key: %s", key.Type()))
+		}
+		value := Eval(pair.Value, env)
+		if isErrorObject(value) {
+			return value
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+	return newError(fmt.Sprintf("identifier not found: %s", node.Value))
+}
+
+// evalExpressions evaluate the call arguments or array elements, the elements of a spread array are inlined
 func evalExpressions(expr []ast.Expression, env *object.Environment) []object.Object {
 	var res []object.Object
 	for _, e := range expr {
+		spread, isSpread := e.(*ast.SpreadExpression)
+		if isSpread {
+			e = spread.Value
+		}
 		evaluated := Eval(e, env)
 		if isErrorObject(evaluated) {
 			return []object.Object{evaluated}
 		}
-		res = append(res, evaluated)
+		if !isSpread {
+			res = append(res, evaluated)
+			continue
+		}
+		array, ok := evaluated.(*object.Array)
+		if !ok {
+			err := newError(fmt.Sprintf("cannot spread %s, want ARRAY", evaluated.Type()))
+			err.Pos, err.End, err.Stack = spread.Pos(), spread.End(), currentStack(env)
+			return []object.Object{err}
+		}
+		res = append(res, array.Elements...)
 	}
 	return res
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	function, ok := fn.(*object.Function)
-	if !ok {
+// applyFunction call the function with the evaluated arguments, call is the call site and may be nil.
+// env is the environment of the call site
+func applyFunction(fn object.Object, args []object.Object, call *ast.CallExpression, env *object.Environment) object.Object {
+	switch function := fn.(type) {
+	case *object.Function:
+		minArgs, maxArgs := functionArity(function)
+		if err := checkArity(functionName(function, call), minArgs, maxArgs, args, call); err != nil {
+			return err
+		}
+		// the body reports its errors with the stack of the function's environment
+		pushFrame(function.Env, functionName(function, call), call)
+		defer popFrame(function.Env)
+		extendedEnv, err := extendedFnEnv(function, args)
+		if err != nil {
+			return err
+		}
+		evaluated := Eval(function.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		if err := checkArity(function.Name, function.MinArgs, function.MaxArgs, args, call); err != nil {
+			return err
+		}
+		pushFrame(env, function.Name, call)
+		defer popFrame(env)
+		res := function.Fn(args...)
+		if res == nil {
+			return nullObj
+		}
+		if err, ok := res.(*object.Error); ok && err.Stack == nil {
+			// the error is positioned at the call site later, but the builtin's frame is only on the stack now
+			err.Stack = currentStack(env)
+		}
+		return res
+	default:
 		return newError(fmt.Sprintf("not a function: %s", fn.Type()))
 	}
-	extendedEnv := extendedFnEnv(function, args)
-	evaluated := Eval(function.Body, extendedEnv)
-	return unwrapReturnValue(evaluated)
 }
 
-func extendedFnEnv(fn *object.Function, args []object.Object) *object.Environment {
+// functionArity the number of required parameters and the number of all parameters, which is object.Variadic
+// when a rest parameter takes the extra arguments
+func functionArity(fn *object.Function) (minArgs int, maxArgs int) {
+	maxArgs = len(fn.Parameters)
+	minArgs = maxArgs
+	for minArgs > 0 && minArgs <= len(fn.Defaults) && fn.Defaults[minArgs-1] != nil {
+		minArgs--
+	}
+	if fn.Rest != nil {
+		maxArgs = object.Variadic
+	}
+	return minArgs, maxArgs
+}
+
+// checkArity the number of arguments must lie between minArgs and maxArgs, maxArgs is object.Variadic for no upper limit
+func checkArity(name string, minArgs int, maxArgs int, args []object.Object, call *ast.CallExpression) *object.Error {
+	if len(args) >= minArgs && (maxArgs == object.Variadic || len(args) <= maxArgs) {
+		return nil
+	}
+	want := fmt.Sprintf("%d", maxArgs)
+	if maxArgs == object.Variadic {
+		want = fmt.Sprintf("%d or more", minArgs)
+	} else if minArgs != maxArgs {
+		want = fmt.Sprintf("%d..%d", minArgs, maxArgs)
+	}
+	msg := fmt.Sprintf("wrong number of arguments: want=%s, got=%d", want, len(args))
+	if call != nil {
+		msg += fmt.Sprintf(" in call to %s", name)
+	}
+	return newError(msg)
+}
+
+// functionName the name used for the called function in messages, the declared name wins over the name at the call site
+func functionName(fn *object.Function, call *ast.CallExpression) string {
+	if fn.Name != "" {
+		return fn.Name
+	}
+	return calleeName(call)
+}
+
+// calleeName the name of the called identifier
+func calleeName(call *ast.CallExpression) string {
+	if call == nil {
+		return "<anonymous>"
+	}
+	if ident, ok := call.Function.(*ast.Identifier); ok {
+		return ident.Value
+	}
+	return "<anonymous>"
+}
+
+func pushFrame(env *object.Environment, name string, call *ast.CallExpression) {
+	frame := object.Frame{Function: name}
+	if call != nil {
+		frame.Pos = call.Pos()
+	}
+	env.CallStack().Push(frame)
+}
+
+func popFrame(env *object.Environment) {
+	env.CallStack().Pop()
+}
+
+// currentStack return a copy of the call stack of the evaluation env belongs to, innermost call first
+func currentStack(env *object.Environment) []object.Frame {
+	return env.CallStack().Frames()
+}
+
+// extendedFnEnv bind the arguments to the parameter patterns in a new environment enclosed by the function's environment.
+// missing trailing arguments take the default values, which are evaluated in the new environment
+// so they can refer to the preceding parameters
+func extendedFnEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
 	env := object.NewWrappedEnv(fn.Env)
 	for i, p := range fn.Parameters {
-		env.Set(p.Value, args[i])
+		var val object.Object
+		if i < len(args) {
+			val = args[i]
+		} else if val = Eval(fn.Defaults[i], env); isErrorObject(val) {
+			return nil, val.(*object.Error)
+		}
+		if err := bindPattern(p, val, env, false); err != nil {
+			return nil, err
+		}
+	}
+	if fn.Rest != nil {
+		var extra []object.Object
+		if len(args) > len(fn.Parameters) {
+			extra = append(extra, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: extra})
 	}
-	return env
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
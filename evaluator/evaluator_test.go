@@ -1,9 +1,14 @@
 package evaluator
 
 import (
+	"bytes"
+	"fmt"
+	"github.com/GzzyZm/interpreter/ast"
 	"github.com/GzzyZm/interpreter/lexer"
 	"github.com/GzzyZm/interpreter/object"
 	"github.com/GzzyZm/interpreter/parser"
+	"os"
+	"sync"
 	"testing"
 )
 
@@ -27,6 +32,9 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"-7 / 2", -3},
 	}
 
 	for _, tt := range tests {
@@ -35,6 +43,151 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerDivisionByZero(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "modulo by zero"},
+		{"let f = fn(x) { 10 / x }; f(0) + 1", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestIntegerOverflowPolicy(t *testing.T) {
+	defer func(policy OverflowPolicy) { IntegerOverflow = policy }(IntegerOverflow)
+
+	tests := []struct {
+		policy   OverflowPolicy
+		input    string
+		expected string
+	}{
+		{OverflowWrap, "9223372036854775807 + 1", "-9223372036854775808"},
+		{OverflowWrap, "-9223372036854775807 - 2", "9223372036854775807"},
+		{OverflowWrap, "4611686018427387904 * 2", "-9223372036854775808"},
+		{OverflowError, "9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{OverflowError, "-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{OverflowError, "4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{OverflowError, "let min = -9223372036854775807 - 1; -min", "integer overflow: --9223372036854775808"},
+		{OverflowError, "let min = -9223372036854775807 - 1; min / -1", "integer overflow: -9223372036854775808 / -1"},
+		{OverflowError, "9223372036854775807 * 1", "9223372036854775807"},
+		{OverflowPromote, "9223372036854775807 + 1", "9223372036854775808"},
+		{OverflowPromote, "4611686018427387904 * 4", "18446744073709551616"},
+		{OverflowPromote, "let big = 9223372036854775807 * 10; big / 10", "9223372036854775807"},
+		{OverflowPromote, "let big = 9223372036854775807 + 1; big > 9223372036854775807", "true"},
+		{OverflowPromote, "let min = -9223372036854775807 - 1; -min", "9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		IntegerOverflow = tt.policy
+		evaluated := testEval(tt.input)
+		actual := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			actual = errObj.Message
+		}
+		if actual != tt.expected {
+			t.Errorf("policy %d: %q wrong result. expected=%q, got=%q",
+				tt.policy, tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestBigIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"-123456789012345678901234567890", "-123456789012345678901234567890"},
+		{"-9223372036854775808", "-9223372036854775808"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"99999999999999999999 + 1", "100000000000000000000"},
+		{"99999999999999999999 * 99999999999999999999", "9999999999999999999800000000000000000001"},
+		{"100000000000000000000 / 10", "10000000000000000000"},
+		{"100000000000000000000 % 7", "2"},
+		{"100000000000000000000 - 99999999999999999999", "1"},
+		{"99999999999999999999 > 1", "true"},
+		{"1 < 99999999999999999999", "true"},
+		{"99999999999999999999 == 99999999999999999999", "true"},
+		{"99999999999999999999 != 99999999999999999998", "true"},
+		{"9223372036854775808 - 1 == 9223372036854775807", "true"},
+		{"type(99999999999999999999)", "INTEGER"},
+		{`{99999999999999999999: "big"}[99999999999999999999]`, "big"},
+		{"99999999999999999999 / 0", "ERROR: division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		actual := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			actual = "ERROR: " + errObj.Message
+		}
+		if actual != tt.expected {
+			t.Errorf("%q wrong result. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+
+	testIntegerObject(t, testEval("9223372036854775808 - 1"), 9223372036854775807)
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{"-2.5", "-2.5"},
+		{"1e-9", "1e-09"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 + 1", "1.5"},
+		{"3 - 0.5", "2.5"},
+		{"2 * 1.25", "2.5"},
+		{"7 / 2.0", "3.5"},
+		{"7.5 % 2", "1.5"},
+		{"99999999999999999999 * 1.0", "1e+20"},
+		{"1 < 1.5", "true"},
+		{"1.5 > 2", "false"},
+		{"2 == 2.0", "true"},
+		{"2 != 2.5", "true"},
+		{"1.0 / 0", "ERROR: division by zero"},
+		{"1.5 + true", "ERROR: type mismatch: FLOAT + BOOLEAN"},
+		{"int(3.9)", "3"},
+		{"int(-3.9)", "-3"},
+		{"int(1e20)", "100000000000000000000"},
+		{`int("42")`, "42"},
+		{`int("4x")`, `ERROR: could not parse "4x" as integer`},
+		{"int(true)", "ERROR: argument to `int` not supported, got BOOLEAN"},
+		{"float(3)", "3.0"},
+		{`float("2.5")`, "2.5"},
+		{"float(1) / 4", "0.25"},
+		{"type(float(1))", "FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		actual := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			actual = "ERROR: " + errObj.Message
+		}
+		if actual != tt.expected {
+			t.Errorf("%q wrong result. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -59,6 +212,22 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"2.5 >= 2", true},
+		{"99999999999999999999 >= 99999999999999999999", true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 && 0", true},
+		{"false && undefined", false},
+		{"true || undefined", true},
+		{"false && 1 / 0", false},
 	}
 
 	for _, tt := range tests {
@@ -215,6 +384,26 @@ func TestErrorHandling(t *testing.T) {
 			"foobar",
 			"identifier not found: foobar",
 		},
+		{
+			"true && undefined",
+			"identifier not found: undefined",
+		},
+		{
+			`"Hello" - "World"`,
+			"unknown operator: STRING - STRING",
+		},
+		{
+			`"Hello" + 1`,
+			"type mismatch: STRING + INTEGER",
+		},
+		{
+			`{"name": "Monkey"}[fn(x) { x }];`,
+			"unusable as hash key: FUNCTION",
+		},
+		{
+			`{fn(x) { x }: 1};`,
+			"unusable as hash key: FUNCTION",
+		},
 	}
 
 	for _, tt := range tests {
@@ -234,6 +423,197 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+func TestErrorTraceback(t *testing.T) {
+	input := `let divide = fn(a, b) { a / b };
+let compute = fn(x) { divide(x, 0) + 1 };
+fn() { compute(3) }()`
+
+	env := object.NewEnv()
+	evaluated := Eval(testParseProgram(input), env)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Pos.String() != "1:25" || errObj.End.String() != "1:30" {
+		t.Errorf("error span wrong. got=%s-%s", errObj.Pos, errObj.End)
+	}
+
+	expected := `ERROR: 1:25: division by zero
+stack traceback:
+	divide called at 2:23
+	compute called at 3:8
+	<anonymous> called at 3:1`
+	if errObj.Inspect() != expected {
+		t.Errorf("wrong inspect.\nwant:\n%s\ngot:\n%s", expected, errObj.Inspect())
+	}
+
+	if frames := env.CallStack().Frames(); len(frames) != 0 {
+		t.Errorf("call stack not unwound. got=%v", frames)
+	}
+
+	errObj = testEval("len(1)").(*object.Error)
+	if len(errObj.Stack) != 1 || errObj.Stack[0].Function != "len" {
+		t.Errorf("builtin frame missing. got=%v", errObj.Stack)
+	}
+
+	errObj = testEval("1 + foo").(*object.Error)
+	if errObj.Inspect() != "ERROR: 1:5: identifier not found: foo" {
+		t.Errorf("wrong inspect. got=%q", errObj.Inspect())
+	}
+}
+
+func TestConcurrentEvaluation(t *testing.T) {
+	input := `let fail = fn(n) { if (n == 0) { 1 / 0 } else { fail(n - 1) } };
+let loop = fn(i) { if (i == 0) { 0 } else { loop(i - 1) } };
+loop(50);
+fail(%d)`
+
+	var wg sync.WaitGroup
+	for depth := 0; depth < 8; depth++ {
+		wg.Add(1)
+		go func(depth int) {
+			defer wg.Done()
+			// every evaluation has its own environment and so its own call stack
+			errObj, ok := Eval(testParseProgram(fmt.Sprintf(input, depth)), object.NewEnv()).(*object.Error)
+			if !ok {
+				t.Errorf("depth %d: no error object returned", depth)
+				return
+			}
+			if len(errObj.Stack) != depth+1 {
+				t.Errorf("depth %d: wrong traceback. got=%v", depth, errObj.Stack)
+			}
+		}(depth)
+	}
+	wg.Wait()
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn double(x) { x * 2 } double(21)", 42},
+		{"fn fact(n) { if (n <= 1) { 1 } else { n * fact(n - 1) } } fact(10)", 3628800},
+		{"let r = add(1, 2); fn add(a, b) { a + b } r", 3},
+		{`fn isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } }
+fn isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } }
+if (isEven(10) && isOdd(7)) { 1 } else { 0 }`, 1},
+		{`fn outer() {
+	let r = inner();
+	fn inner() { helper() * 2 }
+	fn helper() { 21 }
+	r
+}
+outer()`, 42},
+		{"fn outer() { fn hidden() { 1 } hidden() } outer(); hidden()", "identifier not found: hidden"},
+		{"let f = 1; fn f() { 2 } f", 1},
+		{"fn f() { 1 } f = 5; f", 5},
+		{"const f = 1; fn g() { } g()", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			if evaluated != nil {
+				t.Errorf("%q: expected no value, got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("%q: expected error %q, got=%+v", tt.input, expected, evaluated)
+			}
+		}
+	}
+
+	fn, ok := testEval("fn add(a, b = 1) { a + b } add").(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function")
+	}
+	if fn.Name != "add" || fn.Inspect() != "fun add(a, b = 1) {\n(a + b)\n}" {
+		t.Errorf("function name wrong. name=%q, inspect=%q", fn.Name, fn.Inspect())
+	}
+
+	input := `fn divide(a, b) { a / b }
+let alias = divide;
+alias(1, 0)`
+	errObj, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	if len(errObj.Stack) != 1 || errObj.Stack[0].String() != "divide called at 3:1" {
+		t.Errorf("wrong stack. got=%v", errObj.Stack)
+	}
+
+	// the runtime check is only reached when the parser doesn't know the constant, e.g. in the repl
+	env := object.NewEnv()
+	Eval(parser.New(lexer.New("const f = 1;")).ParseProgram(), env)
+	errObj, ok = Eval(parser.New(lexer.New("let x = 1;\nfn f() { 2 }")).ParseProgram(), env).(*object.Error)
+	if !ok || errObj.Message != "cannot redeclare constant f" || errObj.Pos.String() != "2:4" {
+		t.Errorf("wrong redeclaration error. got=%+v", errObj)
+	}
+	if _, ok := env.Get("x"); ok {
+		t.Errorf("the program ran after its declarations failed")
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let [a, b] = [1, 2]; a * 10 + b", 12},
+		{"let [a, b, ...rest] = [1, 2, 3, 4]; len(rest) * 100 + rest[0] * 10 + rest[1]", 234},
+		{"let [a, ...rest] = [1]; len(rest)", 0},
+		{"let [[a, b], [c]] = [[1, 2], [3]]; a + b + c", 6},
+		{`let {name, age} = {"name": "ann", "age": 30, "city": "x"}; len(name) + age`, 33},
+		{`let [{x}, {y}] = [{"x": 1}, {"y": 2}]; x + y`, 3},
+		{`let f = fn([a, b], {n}) { a + b + n }; f([1, 2], {"n": 3})`, 6},
+		{`let f = fn({n} = {"n": 5}, ...rest) { n + len(rest) }; f()`, 5},
+		{`let f = fn([first, ...others]) { first + len(others) }; f([10, 0, 0])`, 12},
+		{"let [a, b] = [1];", "cannot destructure array of 1 elements into 2 names"},
+		{"let [a] = [1, 2];", "cannot destructure array of 2 elements into 1 names"},
+		{"let [a, b] = 5;", "cannot destructure INTEGER as ARRAY"},
+		{`let {a} = [1];`, "cannot destructure ARRAY as HASH"},
+		{`let {name, age} = {"name": "ann"};`, `key "age" not found in hash`},
+		{`let {a} = {1: 2};`, `key "a" not found in hash`},
+		{"let f = fn([a, b]) { a }; f([1])", "cannot destructure array of 1 elements into 2 names"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	input := `let f = fn(x, {name}) { name };
+f(1, {"nom": 2})`
+	errObj, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned")
+	}
+	if errObj.Pos.String() != "1:16" || errObj.End.String() != "1:20" {
+		t.Errorf("error span wrong. got=%s-%s", errObj.Pos, errObj.End)
+	}
+	if len(errObj.Stack) != 1 || errObj.Stack[0].String() != "f called at 2:1" {
+		t.Errorf("wrong stack. got=%v", errObj.Stack)
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -293,6 +673,61 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let add = fn(a, b) { a + b }; add(1);", "wrong number of arguments: want=2, got=1 in call to add"},
+		{"let add = fn(a, b) { a + b }; add(1, 2, 3);", "wrong number of arguments: want=2, got=3 in call to add"},
+		{"fn(a, b) { a }(1)", "wrong number of arguments: want=2, got=1 in call to <anonymous>"},
+		{"let f = fn(a, b = 10) { a + b }; f(1);", 11},
+		{"let f = fn(a, b = 10) { a + b }; f(1, 2);", 3},
+		{"let f = fn(a, b = a * 2) { a + b }; f(3);", 9},
+		{"let f = fn(a, b = 10) { a + b }; f();", "wrong number of arguments: want=1..2, got=0 in call to f"},
+		{"let f = fn(a = x) { a }; f();", "identifier not found: x"},
+		{"let f = fn(first, ...rest) { len(rest) }; f(1, 2, 3);", 2},
+		{"let f = fn(first, ...rest) { len(rest) }; f(1);", 0},
+		{"let f = fn(...all) { all[0] + all[2] }; f(1, 2, 3);", 4},
+		{"let f = fn(a, b = 5, ...rest) { a + b + len(rest) }; f(1);", 6},
+		{"let f = fn(a, b = 5, ...rest) { a + b + len(rest) }; f(1, 2, 3, 4);", 5},
+		{"let f = fn(first, ...rest) { first }; f();", "wrong number of arguments: want=1 or more, got=0 in call to f"},
+		{"let add = fn(a, b, c) { a + b + c }; add(...[1, 2, 3]);", 6},
+		{"let add = fn(a, b, c) { a + b + c }; add(1, ...[2], ...[], 3);", 6},
+		{"let add = fn(a, b) { a + b }; add(...[1, 2, 3]);", "wrong number of arguments: want=2, got=3 in call to add"},
+		{"let f = fn(...xs) { len(xs) }; f(...[1, 2], ...[3]);", 3},
+		{"let sum = fn(...xs) { let s = 0; for (x in xs) { s += x }; s }; sum(...range(5))", "cannot spread RANGE, want ARRAY"},
+		{"let sum = fn(...xs) { let s = 0; for (x in xs) { s += x }; s }; sum(1, 2, 3, 4)", 10},
+		{"len([...[1, 2], 3, ...[4]])", 4},
+		{"first(...[[7], 1])", "wrong number of arguments: want=1, got=2 in call to first"},
+		{"last(push([], ...[1, 2, 3]))", 3},
+		{"push()", "wrong number of arguments: want=2 or more, got=0 in call to push"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	// the position is only reported by the error, not repeated in its message
+	errObj, ok := testEval("let add = fn(a, b) { a + b }; add(1);").(*object.Error)
+	if !ok || errObj.Pos.String() != "1:31" {
+		t.Errorf("arity error not positioned at the call. got=%+v", errObj)
+	}
+}
+
 func TestEnclosingEnvironments(t *testing.T) {
 	input := `
 
@@ -311,6 +746,832 @@ ourFunction(20) + first + second;`
 	testIntegerObject(t, testEval(input), 70)
 }
 
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `"Hello" + " " + "World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" + "b" == "ab"`, true},
+		{`let s = "x"; s != "x"`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d",
+			len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1]", 2},
+		{"[1, 2, 3][2]", 3},
+		{"let i = 0; [1][i];", 1},
+		{"[1, 2, 3][1 + 1];", 3},
+		{"let myArray = [1, 2, 3]; myArray[2];", 3},
+		{"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", 6},
+		{"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", 2},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[1, 2, 3][-4]", nil},
+		{"[fn(x) { x * 2 }][0](4)", 8},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		trueObj.HashKey():                          5,
+		falseObj.HashKey():                         6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+
+	if inspect := result.Inspect(); inspect != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("Hash has wrong inspect order. got=%q", inspect)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`let key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments: want=1, got=2 in call to len"},
+		{`len([1, 2, 3])`, 3},
+		{`len([])`, 0},
+		{`len({"a": 1})`, 1},
+		{`first([1, 2, 3])`, 1},
+		{`first([])`, nil},
+		{`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
+		{`last([1, 2, 3])`, 3},
+		{`last([])`, nil},
+		{`last(1)`, "argument to `last` must be ARRAY, got INTEGER"},
+		{`rest([1, 2, 3])`, []int{2, 3}},
+		{`rest([])`, nil},
+		{`push([], 1)`, []int{1}},
+		{`push([1], 2, 3)`, []int{1, 2, 3}},
+		{`let a = [1]; push(a, 2); a`, []int{1}},
+		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`type(1)`, "INTEGER"},
+		{`type("a")`, "STRING"},
+		{`type(len)`, "BUILTIN"},
+		{`let len = fn(x) { 42 }; len("a")`, 42},
+		{`len(range(10))`, 10},
+		{`len(range(0, 10, 3))`, 4},
+		{`len(range(5, 0, -2))`, 3},
+		{`len(range(-9223372036854775808, 9223372036854775807, 3))`, 6148914691236517205},
+		{`len(range(9223372036854775807, -9223372036854775808, -9223372036854775808))`, 2},
+		{`len(range(0, 9223372036854775807))`, 9223372036854775807},
+		{`range()`, "wrong number of arguments: want=1..3, got=0 in call to range"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, obj.Message)
+				}
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("wrong string. expected=%q, got=%q", expected, obj.Value)
+				}
+			default:
+				t.Errorf("object is not Error or String. got=%T (%+v)",
+					evaluated, evaluated)
+			}
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("obj not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d",
+					len(expected), len(array.Elements))
+				continue
+			}
+
+			for i, expectedElem := range expected {
+				testIntegerObject(t, array.Elements[i], int64(expectedElem))
+			}
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 10", 11},
+		{"let a = 1; let b = 2; a = b = 3; a + b", 6},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }(); counter(); counter(); counter()", 3},
+		{"let n = 0; let f = fn(n) { n = 5 }; f(1); n", 0},
+		{"let i = 0; let sum = 0; while (i < 5) { i += 1; sum += i; }; sum", 15},
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x }; sum", 6},
+		{"let a = [1, 2, 3]; a[0] = 10; a[-1] *= 5; a[0] + a[2]", 25},
+		{`let h = {"a": 1}; h["a"] += 1; h["b"] = 40; h["a"] + h["b"]`, 42},
+		{"let a = [[1]]; a[0][0] = 7; a[0][0]", 7},
+		{"x = 1", "assignment to undeclared identifier: x"},
+		{"x += 1", "identifier not found: x"},
+		{"let len = 1; len = 2; len", 2},
+		{`let x = 1; x += "a"`, "type mismatch: INTEGER + STRING"},
+		{"let x = 1; x /= 0", "division by zero"},
+		{"let a = [1]; a[1] = 2", "index out of range: 1 with length 1"},
+		{"let a = [1]; a[true] = 2", "index operator not supported: ARRAY[BOOLEAN]"},
+		{"let h = {}; h[fn() {}] = 1", "unusable as hash key: FUNCTION"},
+		{"let s = \"ab\"; s[0] = \"c\"", "index operator not supported: STRING[INTEGER]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	closures := `
+let fns = [];
+for (i in range(3)) { fns = push(fns, fn() { i }); }
+fns[0]() * 100 + fns[1]() * 10 + fns[2]()`
+	testIntegerObject(t, testEval(closures), 12)
+}
+
+func TestElseIfExpressions(t *testing.T) {
+	sign := "let sign = fn(x) { if (x < 0) { -1 } else if (x == 0) { 0 } else { 1 } };"
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{sign + "sign(-5)", -1},
+		{sign + "sign(0)", 0},
+		{sign + "sign(3)", 1},
+		{"if (false) { 1 } else if (false) { 2 }", nil},
+		{"if (false) { 1 } else if (true) { 2 } else if (true) { 3 }", 2},
+		{"if (false) { 1 } else if (x) { 2 }", "identifier not found: x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok || errObj.Message != expected {
+				t.Errorf("%q: expected error %q, got=%+v", tt.input, expected, evaluated)
+			}
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	classify := `let classify = fn(v) {
+	match (v) {
+		0 => "zero",
+		1, 2, 3 => "small",
+		-1 => "minus one",
+		2.5 => "two and a half",
+		"hi" => "greeting",
+		true => "yes",
+		n if type(n) == "INTEGER" && n > 100 => "big " + type(n),
+		_ => "other"
+	}
+};`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{classify + "classify(0)", "zero"},
+		{classify + "classify(2)", "small"},
+		{classify + "classify(-1)", "minus one"},
+		{classify + "classify(2.5)", "two and a half"},
+		{classify + "classify(\"hi\")", "greeting"},
+		{classify + "classify(true)", "yes"},
+		{classify + "classify(1000)", "big INTEGER"},
+		{classify + "classify(50)", "other"},
+		{classify + "classify([1])", "other"},
+		{classify + "classify(false)", "other"},
+		{"match (3) { 3.0 => 1, _ => 2 }", 1},
+		{"match (5) { 1 => 1 }", nil},
+		{"match (5) { x if x < 3 => 1, x if x < 10 => { let y = x * 2; y } }", 10},
+		{"let x = 1; match (5) { x => x }; x", 1},
+		{"match (5) { _ => _ }", "identifier not found: _"},
+		{"match (y) { _ => 1 }", "identifier not found: y"},
+		{"match (5) { x if x + true => 1 }", "type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(x) { match (x) { 0 => { return 10; }, _ => 1 }; 20 }; f(0) + f(1)", 30},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("%q: wrong string. expected=%q, got=%q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("%q: wrong error message. expected=%q, got=%q", tt.input, expected, obj.Message)
+				}
+			default:
+				t.Errorf("%q: object is not String or Error. got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestConstBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const a = 5; a * 2", 10},
+		{"const a = 1; let f = fn() { let a = 2; a }; f() + a", 3},
+		{"const a = [1, 2]; a[0] = 7; a[0]", 7},
+		{"let f = fn() { b = 1 }; const b = 1; f()", "cannot assign to constant b"},
+		{"let f = fn() { b += 1 }; const b = 1; f()", "cannot assign to constant b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	// the parser only knows the declarations of its own input, e.g. one line of the repl
+	env := object.NewEnv()
+	inputs := []struct {
+		input    string
+		expected string
+	}{
+		{"const limit = 10;", ""},
+		{"limit = 20;", "cannot assign to constant limit"},
+		{"let limit = 20;", "cannot redeclare constant limit"},
+		{"const limit = 20;", "cannot redeclare constant limit"},
+		{"let x = 1;", ""},
+		{"const x = 2;", "cannot redeclare x as a constant"},
+	}
+	for _, tt := range inputs {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%q: parser errors %q", tt.input, p.Errors())
+		}
+		evaluated := Eval(program, env)
+		errObj, isErr := evaluated.(*object.Error)
+		if tt.expected == "" {
+			if isErr {
+				t.Errorf("%q: unexpected error %q", tt.input, errObj.Message)
+			}
+			continue
+		}
+		if !isErr || errObj.Message != tt.expected {
+			t.Errorf("%q: wrong result. expected error %q, got=%+v", tt.input, tt.expected, evaluated)
+		}
+	}
+	if limit, _ := env.Get("limit"); limit.Inspect() != "10" {
+		t.Errorf("constant limit changed. got=%s", limit.Inspect())
+	}
+}
+
+func TestWhileLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { i = i + 1; }; i", 5},
+		{"let i = 0; while (true) { i = i + 1; if (i == 3) { break; } }; i", 3},
+		{"let i = 0; let n = 0; while (i < 10) { i = i + 1; if (i % 2 == 0) { continue; } n = n + 1; }; n", 5},
+		{"let i = 0; while (i < 3) { i += 1; let x = i; }; x", "identifier not found: x"},
+		{"let i = 0; while (i < 3) { i += 1; const c = i * 2; }; i", 3},
+		{"while (false) { 1 }", nil},
+		{"let f = fn() { let i = 0; while (true) { i = i + 1; if (i > 3) { return i * 10; } } }; f()", 40},
+		{"while (x) { 1 }", "identifier not found: x"},
+		{"let i = 0; while (i < 3) { i = i + 1; i + true; }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			if evaluated != nil {
+				t.Errorf("%q: expected no value, got=%T (%+v)", tt.input, evaluated, evaluated)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q: no error object returned. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+
+	// testEval ignores parser errors, the ';' after the loop must not be one
+	p := parser.New(lexer.New("let c = 0; while (c < 4) { c += 1 }; c"))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors %q", p.Errors())
+	}
+	testIntegerObject(t, Eval(program, object.NewEnv()), 4)
+}
+
+func TestForLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`for (x in [1, "two", true]) { puts(x) }`, "1\ntwo\ntrue\n"},
+		{`for (k in {"a": 1, "b": 2, "c": 3}) { puts(k) }`, "a\nb\nc\n"},
+		{`for (i in range(3)) { puts(i) }`, "0\n1\n2\n"},
+		{`for (i in range(2, 8, 3)) { puts(i) }`, "2\n5\n"},
+		{`for (i in range(3, 0, -1)) { puts(i) }`, "3\n2\n1\n"},
+		{`for (i in range(3, 0)) { puts(i) }`, ""},
+		{`for (i in range(10)) { if (i == 2) { continue; } if (i == 4) { break; } puts(i) }`, "0\n1\n3\n"},
+		{`for (a in [1, 2]) { for (b in [1, 2, 3]) { if (b > a) { break; } puts(a * 10 + b) } }`, "11\n21\n22\n"},
+		{`let x = 7; for (x in [1]) { }; puts(x)`, "7\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Output = &out
+		evaluated := testEval(tt.input)
+		Output = os.Stdout
+
+		if isErrorObject(evaluated) {
+			t.Errorf("%q: unexpected error %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: wrong output. expected=%q, got=%q", tt.input, tt.expected, out.String())
+		}
+	}
+
+	p := parser.New(lexer.New("let s = 0; for (x in [1, 2, 3]) { s += x }; s"))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors %q", p.Errors())
+	}
+	testIntegerObject(t, Eval(program, object.NewEnv()), 6)
+
+	find := `
+let find = fn(items, target) {
+	for (item in items) {
+		if (item == target) { return true; }
+	}
+	false
+};
+[find([1, 2, 3], 2), find([1, 2, 3], 4)]`
+	array, ok := testEval(find).(*object.Array)
+	if !ok || len(array.Elements) != 2 {
+		t.Fatalf("find returned wrong result. got=%v", array)
+	}
+	testBooleanObject(t, array.Elements[0], true)
+	testBooleanObject(t, array.Elements[1], false)
+
+	errs := []struct {
+		input    string
+		expected string
+	}{
+		{"for (x in 5) { x }", "cannot iterate over INTEGER"},
+		{"for (x in [1]) { x + \"a\" }", "type mismatch: INTEGER + STRING"},
+		{"range(1, 2, 0)", "range step must not be zero"},
+		{"range(\"a\")", "argument to `range` must be INTEGER, got STRING"},
+		{"range(9223372036854775808)", "range bound out of int64 range"},
+		{"range(0, -9223372036854775809, -1)", "range bound out of int64 range"},
+		{"range(-9223372036854775808, 9223372036854775807)", "range has more than 9223372036854775807 integers"},
+		{"range(-9223372036854775808, 9223372036854775807, 2)", "range has more than 9223372036854775807 integers"},
+	}
+	for _, tt := range errs {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestPutsBuiltin(t *testing.T) {
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+
+	testNullObject(t, testEval(`puts("hello", 1, [true])`))
+
+	if out.String() != "hello\n1\n[true]\n" {
+		t.Errorf("puts wrote wrong output. got=%q", out.String())
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	RegisterBuiltin("double", func(args ...object.Object) object.Object {
+		return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+	})
+	defer delete(builtins, "double")
+
+	testIntegerObject(t, testEval("double(21)"), 42)
+
+	RegisterBuiltinArity("count", 1, object.Variadic, func(args ...object.Object) object.Object {
+		return &object.Integer{Value: int64(len(args))}
+	})
+	defer delete(builtins, "count")
+
+	testIntegerObject(t, testEval("count(1, 2, ...[3, 4])"), 4)
+	errObj, ok := testEval("count()").(*object.Error)
+	if !ok || errObj.Message != "wrong number of arguments: want=1 or more, got=0 in call to count" {
+		t.Errorf("arity not checked. got=%+v", errObj)
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+		{`quote(unquote(4))`, `4`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote(1.5))`, `1.5`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{`let q = quote(4 + 4); quote(unquote(4 + 4) + unquote(q))`, `(8 + (4 + 4))`},
+		// the quoted expression of the body isn't changed by the first call
+		{`let f = fn(x) { quote(unquote(x) + 1) }; f(1); f(2)`, `(2 + 1)`},
+		{`let f = fn(x) { quote(unquote(x) + 1) }; let a = f(1); let b = f(2); a`, `(1 + 1)`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Errorf("%q: expected *object.Quote. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if quote.Node == nil {
+			t.Errorf("%q: quote.Node is nil", tt.input)
+			continue
+		}
+		if actual := quote.Node.PrintNode(); actual != tt.expected {
+			t.Errorf("%q: not equal. want=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(1, 2)`, "wrong number of arguments: want=1, got=2 in call to quote"},
+		{`quote(unquote())`, "wrong number of arguments: want=1, got=0 in call to unquote"},
+		{`quote(unquote([1]))`, "cannot unquote ARRAY"},
+		{`quote(unquote(nope))`, "identifier not found: nope"},
+	}
+
+	for _, tt := range errorTests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%q: wrong error message. want=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+
+	errObj, ok := testEval(`quote(unquote())`).(*object.Error)
+	if !ok || errObj.Pos.String() != "1:7" {
+		t.Errorf("unquote arity error not positioned at the unquote call. got=%+v", errObj)
+	}
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+let number = 1;
+let function = fn(x, y) { x + y };
+let mymacro = macro(x, y) { x + y; };
+`
+	env := object.NewEnv()
+	program := testParseProgram(input)
+
+	DefineMacros(program, env)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+	for _, name := range []string{"number", "function"} {
+		if _, ok := env.Get(name); ok {
+			t.Fatalf("%s should not be defined", name)
+		}
+	}
+
+	obj, ok := env.Get("mymacro")
+	if !ok {
+		t.Fatalf("macro not in environment")
+	}
+	macro, ok := obj.(*object.Macro)
+	if !ok {
+		t.Fatalf("object is not Macro. got=%T (%+v)", obj, obj)
+	}
+	if len(macro.Parameters) != 2 || macro.Parameters[0].Value != "x" || macro.Parameters[1].Value != "y" {
+		t.Fatalf("wrong macro parameters. got=%v", macro.Parameters)
+	}
+	if actual := macro.Body.PrintNode(); actual != "(x + y)" {
+		t.Fatalf("body is not %q. got=%q", "(x + y)", actual)
+	}
+}
+
+func TestExpandMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infixExpression = macro() { quote(1 + 2); };
+			infixExpression();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			`let twice = macro(x) { quote(unquote(x) + unquote(x)); };
+			let f = fn() { twice(1) };`,
+			`let f = fn() { 1 + 1 };`,
+		},
+		{
+			`let m = macro(a, b) { quote(unquote(b) - unquote(a)) };
+			let x = m(5, 10);
+			let y = m(1, 100);`,
+			`let x = 10 - 5; let y = 100 - 1;`,
+		},
+	}
+
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+
+		env := object.NewEnv()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		if err != nil {
+			t.Errorf("%q: unexpected error %q", tt.input, err.Message)
+			continue
+		}
+
+		if expanded.PrintNode() != expected.PrintNode() {
+			t.Errorf("not equal. want=%q, got=%q", expected.PrintNode(), expanded.PrintNode())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let m = macro(a) { quote(a) }; m(1, 2)`,
+			"wrong number of arguments: want=1, got=2 in call to m",
+		},
+		{
+			`let m = macro() { 1 }; m()`,
+			"macro m must return a quote, got INTEGER",
+		},
+		{
+			`let m = macro() { nope }; m()`,
+			"identifier not found: nope",
+		},
+	}
+
+	for _, tt := range errorTests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnv()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		if err == nil {
+			t.Errorf("%q: no error returned", tt.input)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("%q: wrong error message. want=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+
+	// every expansion of a macro gets its own copy of the quoted body
+	input := `let unless = macro(cond, a, b) { quote(if (!(unquote(cond))) { unquote(a) } else { unquote(b) }) };
+let m = macro(a, b) { quote(unquote(b) - unquote(a)) };
+[m(5, 10), m(1, 100), unless(1 > 2, "first", "second"), unless(2 > 1, "first", "second")]`
+	program := testParseProgram(input)
+	env := object.NewEnv()
+	DefineMacros(program, env)
+	expanded, err := ExpandMacros(program, env)
+	if err != nil {
+		t.Fatalf("unexpected error %q", err.Message)
+	}
+	if actual := Eval(expanded, object.NewEnv()).Inspect(); actual != `[5, 99, first, second]` {
+		t.Errorf("macros expanded wrong. got=%s", actual)
+	}
+
+	// the macros are not visible to the evaluated program
+	errObj, ok := testEval(`let m = macro() { quote(1) }; m()`).(*object.Error)
+	if !ok || errObj.Message != "macros can only be defined by top-level let statements" {
+		t.Errorf("macro literal evaluated. got=%+v", errObj)
+	}
+}
+
+func testParseProgram(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	return p.ParseProgram()
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
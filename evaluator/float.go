@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/GzzyZm/interpreter/object"
+)
+
+func evalFloatInfixExpression(op string, lValue float64, rValue float64) object.Object {
+	switch op {
+	case "+":
+		return &object.Float{Value: lValue + rValue}
+	case "-":
+		return &object.Float{Value: lValue - rValue}
+	case "*":
+		return &object.Float{Value: lValue * rValue}
+	case "/":
+		if rValue == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: lValue / rValue}
+	case "%":
+		if rValue == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(lValue, rValue)}
+	case "<":
+		return booleanNativeToObj(lValue < rValue)
+	case ">":
+		return booleanNativeToObj(lValue > rValue)
+	case "<=":
+		return booleanNativeToObj(lValue <= rValue)
+	case ">=":
+		return booleanNativeToObj(lValue >= rValue)
+	case "==":
+		return booleanNativeToObj(lValue == rValue)
+	case "!=":
+		return booleanNativeToObj(lValue != rValue)
+	default:
+		return newError(fmt.Sprintf("unknown operator: %s %s %s", object.FloatObj, op, object.FloatObj))
+	}
+}
+
+// isNumber judge the object is or isn't an integer or a float
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.IntegerObj || obj.Type() == object.FloatObj
+}
+
+func toFloat(obj object.Object) float64 {
+	switch number := obj.(type) {
+	case *object.Float:
+		return number.Value
+	case *object.Integer:
+		return float64(number.Value)
+	case *object.BigInt:
+		value, _ := new(big.Float).SetInt(number.Value).Float64()
+		return value
+	default:
+		return math.NaN()
+	}
+}
@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// OverflowPolicy decide what happens when the result of integer arithmetic doesn't fit into int64
+type OverflowPolicy int
+
+const (
+	OverflowWrap    OverflowPolicy = iota // wrap around like go's int64 arithmetic
+	OverflowError                         // return an error object
+	OverflowPromote                       // promote the result to an arbitrary precision integer
+)
+
+// IntegerOverflow the overflow policy used by integer arithmetic
+var IntegerOverflow = OverflowPromote
+
+func evalMinusOperationExpression(expr object.Object) object.Object {
+	switch integer := expr.(type) {
+	case *object.Integer:
+		if integer.Value == math.MinInt64 {
+			return integerOverflow("-", expr, nil, integer.Value)
+		}
+		return &object.Integer{Value: -integer.Value}
+	case *object.BigInt:
+		return normalizeBigInt(new(big.Int).Neg(integer.Value))
+	case *object.Float:
+		return &object.Float{Value: -integer.Value}
+	default:
+		return newError(fmt.Sprintf("unknown operator: -%s", expr.Type()))
+	}
+}
+
+func evalIntegerInfixExpression(op string, lExpr object.Object, rExpr object.Object) object.Object {
+	lInteger, lOk := lExpr.(*object.Integer)
+	rInteger, rOk := rExpr.(*object.Integer)
+	if !lOk || !rOk {
+		return evalBigIntInfixExpression(op, toBigInt(lExpr), toBigInt(rExpr))
+	}
+	lValue := lInteger.Value
+	rValue := rInteger.Value
+	switch op {
+	case "+":
+		res := lValue + rValue
+		if (lValue > 0 && rValue > 0 && res < 0) || (lValue < 0 && rValue < 0 && res >= 0) {
+			return integerOverflow(op, lExpr, rExpr, res)
+		}
+		return &object.Integer{Value: res}
+	case "-":
+		res := lValue - rValue
+		if (lValue >= 0 && rValue < 0 && res < 0) || (lValue < 0 && rValue > 0 && res >= 0) {
+			return integerOverflow(op, lExpr, rExpr, res)
+		}
+		return &object.Integer{Value: res}
+	case "*":
+		res := lValue * rValue
+		if lValue != 0 && (res/lValue != rValue || (lValue == -1 && rValue == math.MinInt64)) {
+			return integerOverflow(op, lExpr, rExpr, res)
+		}
+		return &object.Integer{Value: res}
+	case "/":
+		if rValue == 0 {
+			return newError("division by zero")
+		}
+		if lValue == math.MinInt64 && rValue == -1 {
+			return integerOverflow(op, lExpr, rExpr, lValue)
+		}
+		return &object.Integer{Value: lValue / rValue}
+	case "%":
+		if rValue == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Integer{Value: lValue % rValue}
+	case "<":
+		return booleanNativeToObj(lValue < rValue)
+	case ">":
+		return booleanNativeToObj(lValue > rValue)
+	case "<=":
+		return booleanNativeToObj(lValue <= rValue)
+	case ">=":
+		return booleanNativeToObj(lValue >= rValue)
+	case "==":
+		return booleanNativeToObj(lValue == rValue)
+	case "!=":
+		return booleanNativeToObj(lValue != rValue)
+	default:
+		return newError(fmt.Sprintf("unknown operator: %s %s %s", lExpr.Type(), op, rExpr.Type()))
+	}
+}
+
+// evalBigIntInfixExpression the division truncates toward zero like the int64 one
+func evalBigIntInfixExpression(op string, lValue *big.Int, rValue *big.Int) object.Object {
+	switch op {
+	case "+":
+		return normalizeBigInt(new(big.Int).Add(lValue, rValue))
+	case "-":
+		return normalizeBigInt(new(big.Int).Sub(lValue, rValue))
+	case "*":
+		return normalizeBigInt(new(big.Int).Mul(lValue, rValue))
+	case "/":
+		if rValue.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return normalizeBigInt(new(big.Int).Quo(lValue, rValue))
+	case "%":
+		if rValue.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		return normalizeBigInt(new(big.Int).Rem(lValue, rValue))
+	case "<":
+		return booleanNativeToObj(lValue.Cmp(rValue) < 0)
+	case ">":
+		return booleanNativeToObj(lValue.Cmp(rValue) > 0)
+	case "<=":
+		return booleanNativeToObj(lValue.Cmp(rValue) <= 0)
+	case ">=":
+		return booleanNativeToObj(lValue.Cmp(rValue) >= 0)
+	case "==":
+		return booleanNativeToObj(lValue.Cmp(rValue) == 0)
+	case "!=":
+		return booleanNativeToObj(lValue.Cmp(rValue) != 0)
+	default:
+		return newError(fmt.Sprintf("unknown operator: %s %s %s", object.IntegerObj, op, object.IntegerObj))
+	}
+}
+
+// integerOverflow apply the overflow policy, wrapped is the result of the wrapping int64 arithmetic.
+// rExpr is nil for prefix operators
+func integerOverflow(op string, lExpr object.Object, rExpr object.Object, wrapped int64) object.Object {
+	switch IntegerOverflow {
+	case OverflowError:
+		if rExpr == nil {
+			return newError(fmt.Sprintf("integer overflow: %s%s", op, lExpr.Inspect()))
+		}
+		return newError(fmt.Sprintf("integer overflow: %s %s %s", lExpr.Inspect(), op, rExpr.Inspect()))
+	case OverflowPromote:
+		if rExpr == nil {
+			return evalMinusOperationExpression(&object.BigInt{Value: toBigInt(lExpr)})
+		}
+		return evalBigIntInfixExpression(op, toBigInt(lExpr), toBigInt(rExpr))
+	default:
+		return &object.Integer{Value: wrapped}
+	}
+}
+
+func toBigInt(obj object.Object) *big.Int {
+	switch integer := obj.(type) {
+	case *object.BigInt:
+		return integer.Value
+	case *object.Integer:
+		return big.NewInt(integer.Value)
+	default:
+		return nil
+	}
+}
+
+// normalizeBigInt an integer which fits into int64 is always represented by object.Integer
+func normalizeBigInt(value *big.Int) object.Object {
+	if value.IsInt64() {
+		return &object.Integer{Value: value.Int64()}
+	}
+	return &object.BigInt{Value: value}
+}
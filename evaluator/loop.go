@@ -0,0 +1,95 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// evalWhileStatement every iteration evaluates the body in a new environment enclosed by env like evalForStatement,
+// so the declarations of one iteration don't clash with those of the next
+func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condObj := Eval(node.Condition, env)
+		if isErrorObject(condObj) {
+			return condObj
+		}
+		if !isTruth(condObj) {
+			return nil
+		}
+		if res, done := loopSignal(Eval(node.Body, object.NewWrappedEnv(env))); done {
+			return res
+		}
+	}
+}
+
+// evalForStatement every iteration binds the loop variable in a new environment enclosed by env,
+// so the closures created in the body capture the value of their own iteration
+func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isErrorObject(iterable) {
+		return iterable
+	}
+	if !isIterable(iterable) {
+		return newError(fmt.Sprintf("cannot iterate over %s", iterable.Type()))
+	}
+	var res object.Object
+	iterate(iterable, func(elem object.Object) bool {
+		iterEnv := object.NewWrappedEnv(env)
+		iterEnv.Set(node.Variable.Value, elem)
+		var done bool
+		res, done = loopSignal(Eval(node.Body, iterEnv))
+		return !done
+	})
+	return res
+}
+
+// loopSignal decide how the loop goes on after its body evaluated to obj.
+// done is true when the loop ends, res is then the result of the whole loop
+func loopSignal(obj object.Object) (res object.Object, done bool) {
+	if obj == nil {
+		return nil, false
+	}
+	switch obj.Type() {
+	case object.BreakObj:
+		return nil, true
+	case object.ReturnObj, object.ErrorObj:
+		return obj, true
+	}
+	return nil, false
+}
+
+func isIterable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Array, *object.Hash, *object.Range:
+		return true
+	}
+	return false
+}
+
+// iterate call yield with the elements of an array, the keys of a hash or the integers of a range
+// until yield returns false. the elements of an array and the keys of a hash are taken before the first call
+func iterate(iterable object.Object, yield func(object.Object) bool) {
+	switch it := iterable.(type) {
+	case *object.Array:
+		elements := append([]object.Object(nil), it.Elements...)
+		for _, elem := range elements {
+			if !yield(elem) {
+				return
+			}
+		}
+	case *object.Hash:
+		for _, pair := range it.Items() {
+			if !yield(pair.Key) {
+				return
+			}
+		}
+	case *object.Range:
+		for i, n := int64(0), it.Len(); i < n; i++ {
+			if !yield(&object.Integer{Value: it.Start + i*it.Step}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// DefineMacros move the top-level let statements binding a macro literal from the program into env.
+// env is the macro environment, it must be kept apart from the environment the program is evaluated in
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := program.Statements[:0]
+	for _, stmt := range program.Statements {
+		if name, macro, ok := macroDefinition(stmt); ok {
+			env.Set(name.Value, &object.Macro{
+				Name:       name.Value,
+				Parameters: macro.Parameters,
+				Body:       macro.Body,
+				Env:        env,
+			})
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	program.Statements = statements
+}
+
+func macroDefinition(stmt ast.Statement) (*ast.Identifier, *ast.MacroLiteral, bool) {
+	let, ok := stmt.(*ast.LetStatement)
+	if !ok || let.Name == nil {
+		return nil, nil, false
+	}
+	macro, ok := let.Value.(*ast.MacroLiteral)
+	return let.Name, macro, ok
+}
+
+// ExpandMacros replace the calls of the macros defined in env by the quoted ast the macros return.
+// the arguments are passed to the macro unevaluated, as quotes. the first failing expansion is returned as error
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	expanded := ast.Rewrite(program, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || err != nil {
+			return node
+		}
+		macro, ok := macroOf(call, env)
+		if !ok {
+			return node
+		}
+		args := make([]object.Object, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = &object.Quote{Node: arg}
+		}
+		if err = checkArity(macro.Name, len(macro.Parameters), len(macro.Parameters), args, call); err != nil {
+			err.Pos, err.End = call.Pos(), call.End()
+			return node
+		}
+
+		macroEnv := object.NewWrappedEnv(macro.Env)
+		for i, param := range macro.Parameters {
+			macroEnv.Set(param.Value, args[i])
+		}
+		pushFrame(macroEnv, macro.Name, call)
+		evaluated := unwrapReturnValue(Eval(macro.Body, macroEnv))
+		popFrame(macroEnv)
+
+		switch res := evaluated.(type) {
+		case *object.Quote:
+			return res.Node
+		case *object.Error:
+			err = res
+		default:
+			err = newError(fmt.Sprintf("macro %s must return a quote, got %s", macro.Name, typeOf(evaluated)))
+			err.Pos, err.End = call.Pos(), call.End()
+		}
+		return node
+	})
+	if err != nil {
+		return program, err
+	}
+	return expanded, nil
+}
+
+func macroOf(call *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, false
+	}
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, false
+	}
+	macro, ok := obj.(*object.Macro)
+	return macro, ok
+}
+
+// typeOf the type of the object in messages, a missing value is reported as NULL
+func typeOf(obj object.Object) object.Type {
+	if obj == nil {
+		return object.NullObj
+	}
+	return obj.Type()
+}
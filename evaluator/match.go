@@ -0,0 +1,54 @@
+package evaluator
+
+import (
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+)
+
+// evalMatchExpression evaluate the body of the first arm whose pattern matches the subject and whose guard holds,
+// like an if expression without alternative it yields null when no arm matches
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isErrorObject(subject) {
+		return subject
+	}
+	for _, arm := range node.Arms {
+		for _, pattern := range arm.Patterns {
+			// every arm is evaluated in its own environment which holds the name bound by the pattern
+			armEnv := object.NewWrappedEnv(env)
+			matched := matchPattern(pattern, subject, armEnv)
+			if isErrorObject(matched) {
+				return matched
+			}
+			if matched != trueObj {
+				continue
+			}
+			if arm.Guard != nil {
+				guard := Eval(arm.Guard, armEnv)
+				if isErrorObject(guard) {
+					return guard
+				}
+				if !isTruth(guard) {
+					continue
+				}
+			}
+			return Eval(arm.Body, armEnv)
+		}
+	}
+	return nullObj
+}
+
+// matchPattern report whether the subject matches the pattern, a name pattern binds the subject in env
+func matchPattern(pattern ast.Expression, subject object.Object, env *object.Environment) object.Object {
+	if ident, ok := pattern.(*ast.Identifier); ok {
+		if ident.Value != ast.Wildcard {
+			env.Set(ident.Value, subject)
+		}
+		return trueObj
+	}
+	literal := Eval(pattern, env)
+	if isErrorObject(literal) {
+		return literal
+	}
+	return evalInfixExpression("==", subject, literal)
+}
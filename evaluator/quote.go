@@ -0,0 +1,77 @@
+package evaluator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/object"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// isSpecialCall report whether the call is the special form name(...), whose arguments aren't evaluated before the call
+func isSpecialCall(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+// evalQuote return the argument of quote(...) unevaluated, except for the unquote(...) calls inside it
+// which are replaced by the ast of their evaluated argument
+func evalQuote(call *ast.CallExpression, env *object.Environment) object.Object {
+	if len(call.Arguments) != 1 {
+		return newError(fmt.Sprintf("wrong number of arguments: want=1, got=%d in call to quote", len(call.Arguments)))
+	}
+	var err *object.Error
+	// the argument is part of a body which may be evaluated again, only a copy of it is rewritten
+	node := ast.Rewrite(ast.Copy(call.Arguments[0]), func(node ast.Node) ast.Node {
+		unquote, ok := node.(*ast.CallExpression)
+		if !ok || !isSpecialCall(unquote, "unquote") || err != nil {
+			return node
+		}
+		if len(unquote.Arguments) != 1 {
+			err = newError(fmt.Sprintf("wrong number of arguments: want=1, got=%d in call to unquote", len(unquote.Arguments)))
+			err.Pos, err.End, err.Stack = unquote.Pos(), unquote.End(), currentStack(env)
+			return node
+		}
+		evaluated := Eval(unquote.Arguments[0], env)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			err = errObj
+			return node
+		}
+		converted := objectToNode(evaluated)
+		if converted == nil {
+			err = newError(fmt.Sprintf("cannot unquote %s", evaluated.Type()))
+			err.Pos, err.End, err.Stack = unquote.Pos(), unquote.End(), currentStack(env)
+			return node
+		}
+		return converted
+	})
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+// objectToNode return the literal producing the object, nil for the objects without literal
+func objectToNode(obj object.Object) ast.Node {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		literal := strconv.FormatInt(obj.Value, 10)
+		return &ast.Integer{Token: token.New(token.INT, literal), Value: obj.Value}
+	case *object.BigInt:
+		return &ast.Integer{Token: token.New(token.INT, obj.Value.String()), Big: obj.Value}
+	case *object.Float:
+		return &ast.FloatLiteral{Token: token.New(token.FLOAT, obj.Inspect()), Value: obj.Value}
+	case *object.String:
+		return &ast.StringLiteral{Token: token.New(token.STRING, obj.Value), Value: obj.Value}
+	case *object.Boolean:
+		if obj.Value {
+			return &ast.Boolean{Token: token.New(token.TRUE, "true"), Value: true}
+		}
+		return &ast.Boolean{Token: token.New(token.FALSE, "false"), Value: false}
+	case *object.Quote:
+		return ast.Copy(obj.Node)
+	default:
+		return nil
+	}
+}
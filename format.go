@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/GzzyZm/interpreter/diagnostics"
+	"github.com/GzzyZm/interpreter/parser"
+	"github.com/GzzyZm/interpreter/printer"
+)
+
+// runFmt the fmt subcommand, it formats the files given as arguments or the standard input and returns the exit status:
+// 0 on success, 1 when a file can't be formatted or isn't formatted in --check mode and 2 for a usage error
+func runFmt(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	check := flags.Bool("check", false, "list the files whose formatting differs and exit with status 1 if there is one")
+	write := flags.Bool("write", false, "write the result to the files instead of the standard output")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: interpreter fmt [--check | --write] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *check && *write {
+		fmt.Fprintln(stderr, "fmt: --check and --write can't be used together")
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "fmt: --write needs files to write to")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "fmt: %v\n", err)
+			return 1
+		}
+		return formatSource("<stdin>", src, *check, false, stdout, stderr)
+	}
+
+	status := 0
+	for _, filename := range files {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "fmt: %v\n", err)
+			status = 1
+			continue
+		}
+		if s := formatSource(filename, src, *check, *write, stdout, stderr); s != 0 {
+			status = s
+		}
+	}
+	return status
+}
+
+// formatSource format one source, check only reports whether it is formatted and write replaces the file
+func formatSource(filename string, src []byte, check bool, write bool, stdout io.Writer, stderr io.Writer) int {
+	formatted, err := printer.Format(filename, src)
+	if err != nil {
+		var parseErrs parser.ErrorList
+		if !errors.As(err, &parseErrs) {
+			fmt.Fprintf(stderr, "fmt: %v\n", err)
+			return 1
+		}
+		renderer := diagnostics.NewFileRenderer(filename, string(src), diagnostics.ColorEnabled(stderr))
+		for _, parseErr := range parseErrs {
+			if err := renderer.Render(stderr, diagnostics.FromParseError(parseErr)); err != nil {
+				return 1
+			}
+		}
+		return 1
+	}
+
+	switch {
+	case check:
+		if !bytes.Equal(src, formatted) {
+			fmt.Fprintln(stdout, filename)
+			return 1
+		}
+	case write:
+		if bytes.Equal(src, formatted) {
+			return 0
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "fmt: %v\n", err)
+			return 1
+		}
+		if err := os.WriteFile(filename, formatted, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(stderr, "fmt: %v\n", err)
+			return 1
+		}
+	default:
+		if _, err := stdout.Write(formatted); err != nil {
+			return 1
+		}
+	}
+	return 0
+}
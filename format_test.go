@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	unformattedSource = "let x=1+2\n"
+	formattedSource   = "let x = 1 + 2;\n"
+)
+
+func TestFmtStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := runFmt(nil, strings.NewReader(unformattedSource), &stdout, &stderr)
+	if status != 0 || stdout.String() != formattedSource || stderr.Len() != 0 {
+		t.Errorf("wrong result. status=%d, stdout=%q, stderr=%q", status, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	status = runFmt([]string{"--check"}, strings.NewReader(unformattedSource), &stdout, &stderr)
+	if status != 1 || stdout.String() != "<stdin>\n" {
+		t.Errorf("--check on stdin wrong. status=%d, stdout=%q", status, stdout.String())
+	}
+}
+
+func TestFmtCheck(t *testing.T) {
+	dir := t.TempDir()
+	formatted := writeSource(t, dir, "formatted.mk", formattedSource, 0o644)
+	unformatted := writeSource(t, dir, "unformatted.mk", unformattedSource, 0o644)
+
+	var stdout, stderr bytes.Buffer
+	status := runFmt([]string{"--check", formatted, unformatted}, nil, &stdout, &stderr)
+	if status != 1 {
+		t.Errorf("wrong status. want=1, got=%d", status)
+	}
+	if stdout.String() != unformatted+"\n" {
+		t.Errorf("wrong files listed. got=%q", stdout.String())
+	}
+	if content := readSource(t, unformatted); content != unformattedSource {
+		t.Errorf("--check changed the file. got=%q", content)
+	}
+
+	stdout.Reset()
+	if status := runFmt([]string{"--check", formatted}, nil, &stdout, &stderr); status != 0 || stdout.Len() != 0 {
+		t.Errorf("formatted file reported. status=%d, stdout=%q", status, stdout.String())
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	dir := t.TempDir()
+	filename := writeSource(t, dir, "main.mk", unformattedSource, 0o600)
+
+	var stdout, stderr bytes.Buffer
+	if status := runFmt([]string{"--write", filename}, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("wrong status. want=0, got=%d, stderr=%q", status, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("--write printed the source. got=%q", stdout.String())
+	}
+	if content := readSource(t, filename); content != formattedSource {
+		t.Errorf("file not rewritten. got=%q", content)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode not kept. want=%v, got=%v", os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestFmtUsageErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--check", "--write", "main.mk"}, "fmt: --check and --write can't be used together"},
+		{[]string{"--write"}, "fmt: --write needs files to write to"},
+		{[]string{"--nope"}, "flag provided but not defined: -nope"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		status := runFmt(tt.args, strings.NewReader(formattedSource), &stdout, &stderr)
+		if status != 2 {
+			t.Errorf("%q: wrong status. want=2, got=%d", tt.args, status)
+		}
+		if !strings.Contains(stderr.String(), tt.expected) {
+			t.Errorf("%q: wrong message. want=%q, got=%q", tt.args, tt.expected, stderr.String())
+		}
+	}
+}
+
+func TestFmtErrors(t *testing.T) {
+	dir := t.TempDir()
+	broken := writeSource(t, dir, "broken.mk", "let = 1;\n", 0o644)
+
+	var stdout, stderr bytes.Buffer
+	status := runFmt([]string{"--write", broken}, nil, &stdout, &stderr)
+	if status != 1 {
+		t.Errorf("wrong status. want=1, got=%d", status)
+	}
+	for _, expected := range []string{
+		"error[P0001]: expected next token type to be IDENTIFIER, got = instead",
+		broken + ":1:5",
+		"let = 1;",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("diagnostic doesn't contain %q. got=%q", expected, stderr.String())
+		}
+	}
+	if content := readSource(t, broken); content != "let = 1;\n" {
+		t.Errorf("broken file rewritten. got=%q", content)
+	}
+
+	stderr.Reset()
+	missing := filepath.Join(dir, "missing.mk")
+	if status := runFmt([]string{missing}, nil, &stdout, &stderr); status != 1 || !strings.Contains(stderr.String(), missing) {
+		t.Errorf("missing file not reported. status=%d, stderr=%q", status, stderr.String())
+	}
+}
+
+func writeSource(t *testing.T, dir string, name string, content string, mode os.FileMode) string {
+	t.Helper()
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func readSource(t *testing.T, filename string) string {
+	t.Helper()
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
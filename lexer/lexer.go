@@ -1,17 +1,41 @@
 package lexer
 
-import "github.com/GzzyZm/interpreter/token"
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// Mode a set of flags controlling the lexer
+type Mode uint
+
+const (
+	ScanComments Mode = 1 << iota // return comments as COMMENT tokens instead of skipping them
+)
 
 // Lexer lexical analysing struct
 type Lexer struct {
 	textToBeParsed string // the string to lexer
 	currChar       byte   // current character
 	currPosition   int    // current currPosition
+	filename       string // name of the source file, may be empty
+	line           int    // line of the current character, starting at 1
+	lineStart      int    // offset of the first character of the current line
+	mode           Mode   // flags controlling the lexer
 }
 
 func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// NewFile create a lexer whose token positions carry the given file name
+func NewFile(filename string, input string) *Lexer {
 	l := &Lexer{
 		textToBeParsed: input,
+		filename:       filename,
+		line:           1,
 	}
 	// initialize the textToBeParsed string which preforms lexical parsing
 	if len(l.textToBeParsed) > 0 {
@@ -20,41 +44,127 @@ func New(input string) *Lexer {
 	return l
 }
 
+// SetMode set the flags controlling the lexer, e.g. ScanComments for tools which need the comments
+func (l *Lexer) SetMode(mode Mode) {
+	l.mode = mode
+}
+
 // ReadToken read the complete one token from the textToBeParsed string which performs lexical parsing
 func (l *Lexer) ReadToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	for l.mode&ScanComments == 0 && l.isCommentStart() {
+		commentPos := l.position()
+		if comment, ok := l.readComment(); !ok {
+			tok = token.New(token.ILLEGAL, comment)
+			tok.Pos = commentPos
+			tok.End = l.position()
+			return tok
+		}
+		l.readNextCharacter()
+		l.skipWhitespace()
+	}
+	startPos := l.position()
 
 	switch l.currChar {
 	case '/':
-		tok = token.New(token.SLASH, l.currChar)
+		if l.isCommentStart() {
+			if comment, ok := l.readComment(); ok {
+				tok = token.New(token.COMMENT, comment)
+			} else {
+				tok = token.New(token.ILLEGAL, comment)
+			}
+		} else if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.SLASH_ASSIGN, "/=")
+		} else {
+			tok = token.New(token.SLASH, l.currChar)
+		}
+	case '%':
+		tok = token.New(token.PERCENT, l.currChar)
 	case '*':
-		tok = token.New(token.ASTERISK, l.currChar)
+		if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.ASTERISK_ASSIGN, "*=")
+		} else {
+			tok = token.New(token.ASTERISK, l.currChar)
+		}
 	case '<':
-		tok = token.New(token.LT, l.currChar)
+		if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.LT_EQ, "<=")
+		} else {
+			tok = token.New(token.LT, l.currChar)
+		}
 	case '>':
-		tok = token.New(token.GT, l.currChar)
+		if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.GT_EQ, ">=")
+		} else {
+			tok = token.New(token.GT, l.currChar)
+		}
+	case '&':
+		if l.peekNextCharacter() == '&' {
+			l.readNextCharacter()
+			tok = token.New(token.AND, "&&")
+		} else {
+			tok = token.New(token.ILLEGAL, l.currChar)
+		}
+	case '|':
+		if l.peekNextCharacter() == '|' {
+			l.readNextCharacter()
+			tok = token.New(token.OR, "||")
+		} else {
+			tok = token.New(token.ILLEGAL, l.currChar)
+		}
+	case '.':
+		if l.peekNextCharacter() == '.' && l.peekCharacter(2) == '.' {
+			l.readNextCharacter()
+			l.readNextCharacter()
+			tok = token.New(token.ELLIPSIS, "...")
+		} else {
+			tok = token.New(token.ILLEGAL, l.currChar)
+		}
 	case ';':
 		tok = token.New(token.SEMICOLON, l.currChar)
+	case ':':
+		tok = token.New(token.COLON, l.currChar)
 	case ',':
 		tok = token.New(token.COMMA, l.currChar)
 	case '{':
 		tok = token.New(token.LBRACE, l.currChar)
 	case '}':
 		tok = token.New(token.RBRACE, l.currChar)
+	case '[':
+		tok = token.New(token.LBRACKET, l.currChar)
+	case ']':
+		tok = token.New(token.RBRACKET, l.currChar)
 	case '(':
 		tok = token.New(token.LPAREN, l.currChar)
 	case ')':
 		tok = token.New(token.RPAREN, l.currChar)
 	case '+':
-		tok = token.New(token.PLUS, l.currChar)
+		if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.PLUS_ASSIGN, "+=")
+		} else {
+			tok = token.New(token.PLUS, l.currChar)
+		}
 	case '-':
-		tok = token.New(token.MINUS, l.currChar)
+		if l.peekNextCharacter() == '=' {
+			l.readNextCharacter()
+			tok = token.New(token.MINUS_ASSIGN, "-=")
+		} else {
+			tok = token.New(token.MINUS, l.currChar)
+		}
 	case '=':
 		if l.peekNextCharacter() == '=' {
 			l.readNextCharacter()
 			tok = token.New(token.EQ, "==")
+		} else if l.peekNextCharacter() == '>' {
+			l.readNextCharacter()
+			tok = token.New(token.ARROW, "=>")
 		} else {
 			tok = token.New(token.ASSIGN, l.currChar)
 		}
@@ -65,6 +175,12 @@ func (l *Lexer) ReadToken() token.Token {
 		} else {
 			tok = token.New(token.BANG, l.currChar)
 		}
+	case '"':
+		if str, ok := l.readString(); ok {
+			tok = token.New(token.STRING, str)
+		} else {
+			tok = token.New(token.ILLEGAL, str)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -73,16 +189,30 @@ func (l *Lexer) ReadToken() token.Token {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 		} else if isDigit(l.currChar) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 		} else {
 			tok = token.New(token.ILLEGAL, l.currChar)
 		}
 	}
 	l.readNextCharacter()
+	tok.Pos = startPos
+	tok.End = l.position()
+	if tok.Type == token.EOF {
+		tok.End = startPos
+	}
 	return tok
 }
 
+// position return the source position of the current character
+func (l *Lexer) position() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.currPosition,
+		Line:     l.line,
+		Column:   l.currPosition - l.lineStart + 1,
+	}
+}
+
 // skipWhitespace skip whitespace
 func (l *Lexer) skipWhitespace() {
 	for l.currChar == ' ' || l.currChar == '\t' || l.currChar == '\n' || l.currChar == '\r' {
@@ -90,16 +220,140 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-// readNumber read the complete one number
-func (l *Lexer) readNumber() string {
+// readNumber read the complete one number, a fraction or an exponent makes it a float, e.g. 3.14 or 1e-9
+func (l *Lexer) readNumber() (string, token.Type) {
+	startPos := l.currPosition
+	tokType := token.Type(token.INT)
+	l.readDigits()
+	if l.peekNextCharacter() == '.' && isDigit(l.peekCharacter(2)) {
+		l.readNextCharacter()
+		l.readDigits()
+		tokType = token.FLOAT
+	}
+	if nc := l.peekNextCharacter(); nc == 'e' || nc == 'E' {
+		exponentLen := 0
+		if sign := l.peekCharacter(2); (sign == '+' || sign == '-') && isDigit(l.peekCharacter(3)) {
+			exponentLen = 2
+		} else if isDigit(sign) {
+			exponentLen = 1
+		}
+		for i := 0; i < exponentLen; i++ {
+			l.readNextCharacter()
+		}
+		if exponentLen > 0 {
+			l.readDigits()
+			tokType = token.FLOAT
+		}
+	}
+	return l.textToBeParsed[startPos : l.currPosition+1], tokType
+}
+
+// readDigits move to the last one of the consecutive digits
+func (l *Lexer) readDigits() {
+	for isDigit(l.peekNextCharacter()) {
+		l.readNextCharacter()
+	}
+}
+
+// isCommentStart judge the current character starts a // or /* comment or not
+func (l *Lexer) isCommentStart() bool {
+	return l.currChar == '/' && (l.peekNextCharacter() == '/' || l.peekNextCharacter() == '*')
+}
+
+// readComment read the complete one comment including its delimiters, the newline ending a line comment isn't included.
+// ok is false for an unterminated block comment
+func (l *Lexer) readComment() (string, bool) {
 	startPos := l.currPosition
+	if l.peekNextCharacter() == '/' {
+		for nc := l.peekNextCharacter(); nc != '\n' && nc != 0; nc = l.peekNextCharacter() {
+			l.readNextCharacter()
+		}
+		return l.textToBeParsed[startPos : l.currPosition+1], true
+	}
+	// skip "/*"
+	l.readNextCharacter()
+	l.readNextCharacter()
 	for {
-		if nc := l.peekNextCharacter(); !isDigit(nc) {
-			break
+		if l.currChar == 0 {
+			return l.textToBeParsed[startPos:l.currPosition], false
+		}
+		if l.currChar == '*' && l.peekNextCharacter() == '/' {
+			l.readNextCharacter()
+			return l.textToBeParsed[startPos : l.currPosition+1], true
+		}
+		l.readNextCharacter()
+	}
+}
+
+// readString read the complete one double-quoted string and decode its escape sequences.
+// when the string is unterminated or contains an invalid escape, the raw text is returned with ok set to false
+func (l *Lexer) readString() (string, bool) {
+	startPos := l.currPosition
+	var out strings.Builder
+	for {
+		l.readNextCharacter()
+		switch l.currChar {
+		case '"':
+			return out.String(), true
+		case 0:
+			return l.textToBeParsed[startPos:l.currPosition], false
+		case '\\':
+			if !l.readEscape(&out) {
+				l.skipString()
+				return l.textToBeParsed[startPos:l.currPosition], false
+			}
+		default:
+			out.WriteByte(l.currChar)
+		}
+	}
+}
+
+// readEscape decode the escape sequence starting at the current backslash
+func (l *Lexer) readEscape(out *strings.Builder) bool {
+	l.readNextCharacter()
+	switch l.currChar {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		// \u{X...}, one to six hex digits
+		if l.peekNextCharacter() != '{' {
+			return false
+		}
+		l.readNextCharacter()
+		startPos := l.currPosition + 1
+		for isHexDigit(l.peekNextCharacter()) {
+			l.readNextCharacter()
+		}
+		digits := l.textToBeParsed[startPos : l.currPosition+1]
+		if l.peekNextCharacter() != '}' || len(digits) == 0 || len(digits) > 6 {
+			return false
+		}
+		l.readNextCharacter()
+		code, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return false
+		}
+		out.WriteRune(rune(code))
+	default:
+		return false
+	}
+	return true
+}
+
+// skipString move to the closing quote of a malformed string so lexing can go on after it
+func (l *Lexer) skipString() {
+	for l.currChar != '"' && l.currChar != 0 {
+		if l.currChar == '\\' && l.peekNextCharacter() != 0 {
+			l.readNextCharacter()
 		}
 		l.readNextCharacter()
 	}
-	return l.textToBeParsed[startPos : l.currPosition+1]
 }
 
 // readIdentifier read the complete one identifier
@@ -116,7 +370,13 @@ func (l *Lexer) readIdentifier() string {
 
 // readNextCharacter set currentCharacter value and move relation currPosition
 func (l *Lexer) readNextCharacter() {
-	l.currPosition++
+	if l.currChar == '\n' {
+		l.line++
+		l.lineStart = l.currPosition + 1
+	}
+	if l.currPosition < len(l.textToBeParsed) {
+		l.currPosition++
+	}
 	if l.currPosition >= len(l.textToBeParsed) {
 		// the currPosition out of bounds
 		l.currChar = 0
@@ -127,12 +387,17 @@ func (l *Lexer) readNextCharacter() {
 
 // peekNextCharacter peek next character for some lexeral unit like == or !=
 func (l *Lexer) peekNextCharacter() byte {
-	nextPos := l.currPosition + 1
-	if nextPos >= len(l.textToBeParsed) {
+	return l.peekCharacter(1)
+}
+
+// peekCharacter peek the character n positions after the current one
+func (l *Lexer) peekCharacter(n int) byte {
+	pos := l.currPosition + n
+	if pos >= len(l.textToBeParsed) {
 		// the currPosition out of bounds
 		return 0
 	}
-	return l.textToBeParsed[nextPos]
+	return l.textToBeParsed[pos]
 }
 
 // isLetter judge current character is or isn't letter.
@@ -141,6 +406,11 @@ func isLetter(ch byte) bool {
 	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ch == '_'
 }
 
+// isHexDigit judge current character is or isn't hexadecimal digit
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
 // isDigit judge current character is or isn't digit
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
@@ -15,7 +15,7 @@ let add = fn(x, y) {
 };
 
 let result = add(five, ten);
-!-/*5;
+!-/ *%5;
 5 < 10 > 5;
 
 if (5 < 10) {
@@ -26,6 +26,12 @@ if (5 < 10) {
 
 10 == 10;
 10 != 9;
+[1, 2];
+{"foo": "bar"}
+a <= b >= c && d || e;
+x += 1 -= 2 *= 3 /= 4;
+_ => 1
+f(...xs)
 `
 
 	tests := []struct {
@@ -72,6 +78,7 @@ if (5 < 10) {
 		{token.MINUS, "-"},
 		{token.SLASH, "/"},
 		{token.ASTERISK, "*"},
+		{token.PERCENT, "%"},
 		{token.INT, "5"},
 		{token.SEMICOLON, ";"},
 		{token.INT, "5"},
@@ -105,6 +112,223 @@ if (5 < 10) {
 		{token.NOT_EQ, "!="},
 		{token.INT, "9"},
 		{token.SEMICOLON, ";"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.INT, "2"},
+		{token.RBRACKET, "]"},
+		{token.SEMICOLON, ";"},
+		{token.LBRACE, "{"},
+		{token.STRING, "foo"},
+		{token.COLON, ":"},
+		{token.STRING, "bar"},
+		{token.RBRACE, "}"},
+		{token.IDENTIFIER, "a"},
+		{token.LT_EQ, "<="},
+		{token.IDENTIFIER, "b"},
+		{token.GT_EQ, ">="},
+		{token.IDENTIFIER, "c"},
+		{token.AND, "&&"},
+		{token.IDENTIFIER, "d"},
+		{token.OR, "||"},
+		{token.IDENTIFIER, "e"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "2"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "3"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "_"},
+		{token.ARROW, "=>"},
+		{token.INT, "1"},
+		{token.IDENTIFIER, "f"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENTIFIER, "xs"},
+		{token.RPAREN, ")"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.ReadToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPosition(t *testing.T) {
+	input := `let x = 10;
+  x == 5`
+
+	tests := []struct {
+		expectedType   token.Type
+		expectedPos    token.Position
+		expectedEndCol int
+	}{
+		{token.LET, token.Position{Filename: "test.mk", Offset: 0, Line: 1, Column: 1}, 4},
+		{token.IDENTIFIER, token.Position{Filename: "test.mk", Offset: 4, Line: 1, Column: 5}, 6},
+		{token.ASSIGN, token.Position{Filename: "test.mk", Offset: 6, Line: 1, Column: 7}, 8},
+		{token.INT, token.Position{Filename: "test.mk", Offset: 8, Line: 1, Column: 9}, 11},
+		{token.SEMICOLON, token.Position{Filename: "test.mk", Offset: 10, Line: 1, Column: 11}, 12},
+		{token.IDENTIFIER, token.Position{Filename: "test.mk", Offset: 14, Line: 2, Column: 3}, 4},
+		{token.EQ, token.Position{Filename: "test.mk", Offset: 16, Line: 2, Column: 5}, 7},
+		{token.INT, token.Position{Filename: "test.mk", Offset: 19, Line: 2, Column: 8}, 9},
+		{token.EOF, token.Position{Filename: "test.mk", Offset: 20, Line: 2, Column: 9}, 9},
+	}
+
+	l := NewFile("test.mk", input)
+
+	for i, tt := range tests {
+		tok := l.ReadToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Fatalf("tests[%d] - position wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End.Column != tt.expectedEndCol {
+			t.Fatalf("tests[%d] - end column wrong. expected=%d, got=%d",
+				i, tt.expectedEndCol, tok.End.Column)
+		}
+	}
+
+	if s := tests[5].expectedPos.String(); s != "test.mk:2:3" {
+		t.Fatalf("position string wrong. expected=%q, got=%q", "test.mk:2:3", s)
+	}
+}
+
+func TestNumberToken(t *testing.T) {
+	input := `5 3.14 1e-9 2.5E+3 7e3 1.x 2e a.5`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.INT, "5"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "2.5E+3"},
+		{token.FLOAT, "7e3"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "."},
+		{token.IDENTIFIER, "x"},
+		{token.INT, "2"},
+		{token.IDENTIFIER, "e"},
+		{token.IDENTIFIER, "a"},
+		{token.ILLEGAL, "."},
+		{token.INT, "5"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.ReadToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 10; // trailing comment
+/* block
+   comment */ x / 2;
+/* unterminated`
+
+	tests := []struct {
+		mode            Mode
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{0, token.LET, "let"},
+		{0, token.IDENTIFIER, "x"},
+		{0, token.ASSIGN, "="},
+		{0, token.INT, "10"},
+		{0, token.SEMICOLON, ";"},
+		{0, token.IDENTIFIER, "x"},
+		{0, token.SLASH, "/"},
+		{0, token.INT, "2"},
+		{0, token.SEMICOLON, ";"},
+		{0, token.ILLEGAL, "/* unterminated"},
+		{0, token.EOF, ""},
+		{ScanComments, token.COMMENT, "// leading comment"},
+		{ScanComments, token.LET, "let"},
+		{ScanComments, token.IDENTIFIER, "x"},
+		{ScanComments, token.ASSIGN, "="},
+		{ScanComments, token.INT, "10"},
+		{ScanComments, token.SEMICOLON, ";"},
+		{ScanComments, token.COMMENT, "// trailing comment"},
+		{ScanComments, token.COMMENT, "/* block\n   comment */"},
+		{ScanComments, token.IDENTIFIER, "x"},
+		{ScanComments, token.SLASH, "/"},
+		{ScanComments, token.INT, "2"},
+		{ScanComments, token.SEMICOLON, ";"},
+		{ScanComments, token.ILLEGAL, "/* unterminated"},
+		{ScanComments, token.EOF, ""},
+	}
+
+	var l *Lexer
+	for i, tt := range tests {
+		if i == 0 || tests[i-1].mode != tt.mode {
+			l = New(input)
+			l.SetMode(tt.mode)
+		}
+		tok := l.ReadToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestStringToken(t *testing.T) {
+	input := `"foobar" "foo bar" "a\nb\t\"c\"\\" "\u{48}\u{1F600}" "bad\q" "open`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.STRING, "foobar"},
+		{token.STRING, "foo bar"},
+		{token.STRING, "a\nb\t\"c\"\\"},
+		{token.STRING, "H\U0001F600"},
+		{token.ILLEGAL, `"bad\q`},
+		{token.ILLEGAL, `"open`},
 		{token.EOF, ""},
 	}
 
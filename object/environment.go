@@ -2,19 +2,27 @@ package object
 
 type Environment struct {
 	store    map[string]Object
+	consts   map[string]bool // the names in store which are bound by const
 	outerEnv *Environment
+	stack    *CallStack // shared by the environments wrapping the same root
 }
 
 func NewEnv() *Environment {
-	return &Environment{store: make(map[string]Object), outerEnv: nil}
+	return &Environment{store: make(map[string]Object), consts: make(map[string]bool), outerEnv: nil, stack: &CallStack{}}
 }
 
 func NewWrappedEnv(outerEnv *Environment) *Environment {
 	env := NewEnv()
 	env.outerEnv = outerEnv
+	env.stack = outerEnv.stack
 	return env
 }
 
+// CallStack return the calls being evaluated in the environment, every root environment made by NewEnv has its own
+func (e *Environment) CallStack() *CallStack {
+	return e.stack
+}
+
 func (e *Environment) Get(key string) (Object, bool) {
 	obj, ok := e.store[key]
 	if !ok && e.outerEnv != nil {
@@ -23,6 +31,68 @@ func (e *Environment) Get(key string) (Object, bool) {
 	return obj, ok
 }
 
-func (e *Environment) Set(key string, obj Object) {
+// Set bind key in this environment, it returns false instead when key is a constant of this environment
+func (e *Environment) Set(key string, obj Object) bool {
+	if e.consts[key] {
+		return false
+	}
 	e.store[key] = obj
+	return true
+}
+
+// SetConst bind key as a constant in this environment, it returns false instead when key is already bound here
+func (e *Environment) SetConst(key string, obj Object) bool {
+	if _, ok := e.store[key]; ok {
+		return false
+	}
+	e.store[key] = obj
+	e.consts[key] = true
+	return true
+}
+
+// IsConst report whether the innermost binding of key is a constant
+func (e *Environment) IsConst(key string) bool {
+	for env := e; env != nil; env = env.outerEnv {
+		if _, ok := env.store[key]; ok {
+			return env.consts[key]
+		}
+	}
+	return false
+}
+
+// Assign update the existing binding of key in the innermost environment which has one,
+// it returns false when key is bound nowhere or its binding is a constant
+func (e *Environment) Assign(key string, obj Object) bool {
+	for env := e; env != nil; env = env.outerEnv {
+		if _, ok := env.store[key]; ok {
+			if env.consts[key] {
+				return false
+			}
+			env.store[key] = obj
+			return true
+		}
+	}
+	return false
+}
+
+// CallStack the frames of the calls being evaluated, innermost call last
+type CallStack struct {
+	frames []Frame
+}
+
+func (s *CallStack) Push(frame Frame) {
+	s.frames = append(s.frames, frame)
+}
+
+func (s *CallStack) Pop() {
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// Frames return a copy of the frames, innermost call first
+func (s *CallStack) Frames() []Frame {
+	frames := make([]Frame, 0, len(s.frames))
+	for i := len(s.frames) - 1; i >= 0; i-- {
+		frames = append(frames, s.frames[i])
+	}
+	return frames
 }
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/token"
+	"hash/fnv"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -11,11 +15,21 @@ type Type string
 
 const (
 	IntegerObj  = "INTEGER"
+	FloatObj    = "FLOAT"
+	StringObj   = "STRING"
 	BooleanObj  = "BOOLEAN"
 	NullObj     = "NULL"
 	ReturnObj   = "RETURN"
 	ErrorObj    = "ERROR"
 	FunctionObj = "FUNCTION"
+	QuoteObj    = "QUOTE"
+	MacroObj    = "MACRO"
+	ArrayObj    = "ARRAY"
+	HashObj     = "HASH"
+	BuiltinObj  = "BUILTIN"
+	RangeObj    = "RANGE"
+	BreakObj    = "BREAK"
+	ContinueObj = "CONTINUE"
 
 	NullValue = "null"
 )
@@ -25,6 +39,18 @@ type Object interface {
 	Inspect() string
 }
 
+// HashKey the stable key of a hashable object, objects with equal values produce equal keys
+type HashKey struct {
+	Type  Type
+	Value uint64
+}
+
+// Hashable implemented by the objects which can be used as hash keys
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
 type Integer struct {
 	Value int64
 }
@@ -35,6 +61,59 @@ func (i *Integer) Type() Type {
 func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// BigInt an integer which doesn't fit into int64, it has the same type as Integer
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() Type {
+	return IntegerObj
+}
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+func (b *BigInt) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(b.Value.String()))
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() Type {
+	return FloatObj
+}
+
+// Inspect always keep a fraction or an exponent so the float can't be mistaken for an integer
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() Type {
+	return StringObj
+}
+func (s *String) Inspect() string {
+	return s.Value
+}
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
 
 type Boolean struct {
 	Value bool
@@ -46,6 +125,13 @@ func (b *Boolean) Type() Type {
 func (b *Boolean) Inspect() string {
 	return fmt.Sprintf("%t", b.Value)
 }
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	}
+	return HashKey{Type: b.Type(), Value: value}
+}
 
 type Null struct{}
 
@@ -67,19 +153,193 @@ func (r *Return) Inspect() string {
 	return r.Value.Inspect()
 }
 
+// Break the signal of a break statement, it unwinds the blocks up to the innermost loop
+type Break struct{}
+
+func (b *Break) Type() Type {
+	return BreakObj
+}
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// Continue the signal of a continue statement, it unwinds the blocks up to the innermost loop
+type Continue struct{}
+
+func (c *Continue) Type() Type {
+	return ContinueObj
+}
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
+// Frame a function call on the call stack
+type Frame struct {
+	Function string         // the function name or <anonymous>
+	Pos      token.Position // the position of the call site
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s called at %s", f.Function, f.Pos)
+}
+
 type Error struct {
 	Message string
+	Pos     token.Position // start of the node which raised the error
+	End     token.Position // position immediately after the node which raised the error
+	Stack   []Frame        // the call stack when the error was raised, innermost call first
 }
 
 func (e *Error) Type() Type {
 	return ErrorObj
 }
+
+// Inspect print the message and the traceback, e.g.
+//
+//	ERROR: 2:12: division by zero
+//	stack traceback:
+//		divide called at 4:1
 func (e *Error) Inspect() string {
-	return fmt.Sprintf("ERROR: %s" + e.Message)
+	var out bytes.Buffer
+	out.WriteString("ERROR: ")
+	if e.Pos.IsValid() {
+		out.WriteString(e.Pos.String() + ": ")
+	}
+	out.WriteString(e.Message)
+	if len(e.Stack) > 0 {
+		out.WriteString("\nstack traceback:")
+		for _, frame := range e.Stack {
+			out.WriteString("\n\t" + frame.String())
+		}
+	}
+	return out.String()
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() Type {
+	return ArrayObj
+}
+func (a *Array) Inspect() string {
+	var elements []string
+	for _, e := range a.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	return fmt.Sprintf("[%s]", strings.Join(elements, ", "))
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash the pairs are indexed by the key's HashKey, keys remember their insertion order
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+func (h *Hash) Type() Type {
+	return HashObj
+}
+func (h *Hash) Inspect() string {
+	var pairs []string
+	for _, pair := range h.Items() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
+// Get lookup the pair stored under the key
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair, ok
+}
+
+// Set store the value under the key, replacing the existing value but keeping its position
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.keys = append(h.keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// Items return the pairs in insertion order
+func (h *Hash) Items() []HashPair {
+	items := make([]HashPair, 0, len(h.keys))
+	for _, k := range h.keys {
+		items = append(items, h.Pairs[k])
+	}
+	return items
+}
+
+// Range the integers from Start up to but not including End, Step apart
+type Range struct {
+	Start int64
+	End   int64
+	Step  int64
+}
+
+func (r *Range) Type() Type {
+	return RangeObj
+}
+func (r *Range) Inspect() string {
+	if r.Step == 1 {
+		return fmt.Sprintf("range(%d, %d)", r.Start, r.End)
+	}
+	return fmt.Sprintf("range(%d, %d, %d)", r.Start, r.End, r.Step)
+}
+
+// Len return the number of integers in the range, range() rejects the ranges whose length doesn't fit into int64
+func (r *Range) Len() int64 {
+	return int64(r.Count())
+}
+
+// Count return the number of integers in the range, it can exceed math.MaxInt64 but never overflows
+func (r *Range) Count() uint64 {
+	// the distance is computed in uint64 so it can't overflow
+	switch {
+	case r.Step > 0 && r.Start < r.End:
+		return (uint64(r.End-r.Start)-1)/uint64(r.Step) + 1
+	case r.Step < 0 && r.Start > r.End:
+		return (uint64(r.Start-r.End)-1)/uint64(-r.Step) + 1
+	default:
+		return 0
+	}
+}
+
+// BuiltinFunction the go implementation of a built-in function
+type BuiltinFunction func(args ...Object) Object
+
+// Variadic the MaxArgs of a builtin which accepts any number of arguments from MinArgs on
+const Variadic = -1
+
+type Builtin struct {
+	Name    string
+	Fn      BuiltinFunction
+	MinArgs int // the arity checked before Fn is called
+	MaxArgs int // Variadic when there is no upper limit
+}
+
+func (b *Builtin) Type() Type {
+	return BuiltinObj
+}
+func (b *Builtin) Inspect() string {
+	return fmt.Sprintf("builtin function %s", b.Name)
 }
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Name       string // the name of a function declaration, empty for an anonymous function
+	Parameters []ast.Pattern
+	Defaults   []ast.Expression // default value of each parameter, nil for the required ones
+	Rest       *ast.Identifier  // the parameter collecting the extra arguments, nil if there is none
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -89,10 +349,41 @@ func (f *Function) Type() Type {
 }
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
-	var ps []string
-	for _, p := range f.Parameters {
-		ps = append(ps, p.PrintNode())
+	out.WriteString("fun")
+	if f.Name != "" {
+		out.WriteString(" " + f.Name)
 	}
-	out.WriteString(fmt.Sprintf("fun(%s) {\n%s\n}", strings.Join(ps, ", "), f.Body.PrintNode()))
+	out.WriteString(fmt.Sprintf("(%s) {\n%s\n}", ast.PrintParameters(f.Parameters, f.Defaults, f.Rest), f.Body.PrintNode()))
 	return out.String()
 }
+
+// Quote an unevaluated ast node, produced by quote(...)
+type Quote struct {
+	Node ast.Node
+}
+
+func (q *Quote) Type() Type {
+	return QuoteObj
+}
+func (q *Quote) Inspect() string {
+	return "QUOTE(" + q.Node.PrintNode() + ")"
+}
+
+// Macro the macro defined by let name = macro(params) { ... }, it lives in the macro environment
+type Macro struct {
+	Name       string
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (m *Macro) Type() Type {
+	return MacroObj
+}
+func (m *Macro) Inspect() string {
+	var params []string
+	for _, p := range m.Parameters {
+		params = append(params, p.PrintNode())
+	}
+	return fmt.Sprintf("macro(%s) {\n%s\n}", strings.Join(params, ", "), m.Body.PrintNode())
+}
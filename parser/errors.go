@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// ErrorCode the stable identifier of a kind of parse error
+type ErrorCode string
+
+const (
+	ErrUnexpectedToken   ErrorCode = "P0001" // the next token isn't the expected one
+	ErrNoPrefixParseFn   ErrorCode = "P0002" // the token can't start an expression
+	ErrInvalidInteger    ErrorCode = "P0003" // the integer literal can't be parsed
+	ErrInvalidFloat      ErrorCode = "P0004" // the float literal can't be parsed
+	ErrParameterOrder    ErrorCode = "P0005" // a required parameter follows an optional one
+	ErrIllegalToken      ErrorCode = "P0006" // the lexer couldn't recognize the source text
+	ErrOutsideLoop       ErrorCode = "P0007" // break or continue outside a loop
+	ErrInvalidAssignment ErrorCode = "P0008" // the left side of an assignment is neither an identifier nor an index expression
+	ErrConstant          ErrorCode = "P0009" // a constant is assigned or redeclared in its scope
+	ErrInvalidPattern    ErrorCode = "P0010" // a match pattern is neither a literal, a name nor _
+	ErrMacroParameter    ErrorCode = "P0011" // a macro parameter is a pattern, has a default value or is a rest parameter
+)
+
+// ParseError a parse error with the source span it refers to
+type ParseError struct {
+	Code     ErrorCode
+	Msg      string
+	Pos      token.Position // start of the offending source
+	End      token.Position // position immediately after the offending source
+	Expected token.Type     // the expected token type, empty if the error isn't about a missing token
+	Found    token.Type     // the token type found instead
+}
+
+// Error return the message prefixed with the position, e.g. "main.mk:1:5: ..."
+func (e *ParseError) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// ErrorList the errors collected while parsing, in source order
+type ErrorList []*ParseError
+
+// Error join the messages with newlines like errors.Join
+func (l ErrorList) Error() string {
+	var msgs []string
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap return the errors so errors.Is and errors.As can inspect every one of them
+func (l ErrorList) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, e := range l {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Err return the list as an error, or nil if the list is empty
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
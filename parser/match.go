@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// parseMatchExpression parse match (subject) { patterns [if guard] => body, ... },
+// the body of an arm is a block or a single expression, which is followed by ',' or ';' unless it is the last arm
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expr := &ast.MatchExpression{Token: p.currToken}
+
+	if !p.expectPeekIs(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	expr.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeekIs(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeekIs(token.LBRACE) {
+		return nil
+	}
+
+	for !p.expectPeekTokenType(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expr.Arms = append(expr.Arms, arm)
+		if p.expectPeekTokenType(token.COMMA) || p.expectPeekTokenType(token.SEMICOLON) {
+			p.nextToken()
+		} else if arm.Body.Token.Type != token.LBRACE && !p.expectPeekTokenType(token.RBRACE) {
+			// an arm whose body is an expression must be separated from the next one,
+			// otherwise a pattern like (x) or [x] would continue the expression
+			p.expectPeekIs(token.COMMA)
+			return nil
+		}
+	}
+	p.nextToken()
+	expr.RBrace = p.currToken
+	return expr
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{}
+	// every arm gets its own environment at runtime, which holds the bound name
+	p.openScope()
+	defer p.closeScope()
+
+	for {
+		pattern := p.parseArmPattern()
+		if pattern == nil {
+			return nil
+		}
+		arm.Patterns = append(arm.Patterns, pattern)
+		if ident, ok := pattern.(*ast.Identifier); ok && ident.Value != ast.Wildcard {
+			p.scope.names[ident.Value] = false
+		}
+		if !p.expectPeekTokenType(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	if p.expectPeekTokenType(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeekIs(token.ARROW) {
+		return nil
+	}
+	p.nextToken()
+
+	if p.expectCurrTokenType(token.LBRACE) {
+		arm.Body = p.parseBlockStatement()
+	} else {
+		bodyToken := p.currToken
+		body := p.parseExpression(LOWEST)
+		if body == nil {
+			return nil
+		}
+		arm.Body = &ast.BlockStatement{
+			Token:      bodyToken,
+			Statements: []ast.Statement{&ast.ExpressionStatement{Token: bodyToken, Expression: body}},
+		}
+	}
+	if p.panicking {
+		return nil
+	}
+	return arm
+}
+
+// parseArmPattern parse a literal, a negative number, the wildcard _ or a name which binds the subject
+func (p *Parser) parseArmPattern() ast.Expression {
+	pattern := p.parseExpression(LOWEST)
+	if pattern == nil {
+		return nil
+	}
+	switch pt := pattern.(type) {
+	case *ast.Identifier, *ast.Integer, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return pattern
+	case *ast.PrefixExpression:
+		switch pt.RightExpr.(type) {
+		case *ast.Integer, *ast.FloatLiteral:
+			if pt.Operator == "-" {
+				return pattern
+			}
+		}
+	}
+	p.addError(&ParseError{
+		Code:  ErrInvalidPattern,
+		Msg:   fmt.Sprintf("invalid pattern: %s", pattern.PrintNode()),
+		Pos:   pattern.Pos(),
+		End:   pattern.End(),
+		Found: p.currToken.Type,
+	})
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/GzzyZm/interpreter/ast"
 	"github.com/GzzyZm/interpreter/lexer"
 	"github.com/GzzyZm/interpreter/token"
+	"math/big"
 	"strconv"
 )
 
@@ -12,24 +13,47 @@ const (
 	// Priority definition
 	_           int = iota
 	LOWEST          // doesn't exist yet
+	ASSIGNMENT      // x = y or x += y
+	LOGICALOR       // ||
+	LOGICALAND      // &&
 	EQUALS          // ==
-	LESSGREATER     // > or <
+	LESSGREATER     // >, <, >= or <=
 	SUM             // + or -
-	PRODUCT         // * or /
+	PRODUCT         // *, / or %
 	PREFIX          // !x or -x
 	CALL            // fn(x)
+	INDEX           // array[index]
 )
 
 var precedences = map[token.Type]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.AND:             LOGICALAND,
+	token.OR:              LOGICALOR,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+}
+
+// Precedence return the binding power of the infix operator t, LOWEST if t isn't an infix operator
+func Precedence(t token.Type) int {
+	if precedence, ok := precedences[t]; ok {
+		return precedence
+	}
+	return LOWEST
 }
 
 type (
@@ -43,39 +67,63 @@ type Parser struct {
 	peekToken      token.Token
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
-	errors         []string // collect exception info during parsing
+	errors         ErrorList // collect exception info during parsing
+	panicking      bool      // an error has been reported and the parser hasn't synchronized yet
+	loopDepth      int       // the number of loops enclosing the current token within the current function
+	scope          *scope    // the declarations visible at the current token
+	braceDepth     int       // the number of '{' minus the number of '}' up to the current token
+	comments       []*ast.Comment
 }
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:              l,
-		errors:         []string{},
+		errors:         ErrorList{},
 		prefixParseFns: make(map[token.Type]prefixParseFn),
 		infixParseFns:  make(map[token.Type]infixParseFn),
+		scope:          newScope(nil),
 	}
 	// initialize the currToken and the peekToken
 	p.nextToken()
 	p.nextToken()
 	// register prefix functions
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.IDENTIFIER, p.parseIdentifier)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	// blocks are only parsed where a statement list is expected, so a brace in expression position is a hash
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	// register infix functions
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 	p.registerInfix(token.LPAREN, p.parseCallFunction)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
 	return p
 }
 
@@ -84,21 +132,39 @@ func (p *Parser) ParseProgram() *ast.Program {
 		Statements: []ast.Statement{},
 	}
 	for !p.expectCurrTokenType(token.EOF) {
+		start := p.statementStart()
 		stmt := p.parseStatement()
+		if p.panicking {
+			// drop the broken statement and go on with the next one
+			p.synchronize(false, start)
+			continue
+		}
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	program.Comments = p.comments
 	return program
 }
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.currToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
+	case token.FUNCTION:
+		if p.expectPeekTokenType(token.IDENTIFIER) {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -107,12 +173,18 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.currToken}
 
-	if !p.expectPeekIs(token.IDENTIFIER) {
+	if p.expectPeekTokenType(token.LBRACKET) || p.expectPeekTokenType(token.LBRACE) {
+		// case let [a, b] = v or let {a, b} = v
+		p.nextToken()
+		if stmt.Pattern = p.parseBindingPattern(); stmt.Pattern == nil {
+			return nil
+		}
+	} else if !p.expectPeekIs(token.IDENTIFIER) {
 		return nil
+	} else {
+		stmt.Name = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
-
 	if !p.expectPeekIs(token.ASSIGN) {
 		return nil
 	}
@@ -120,6 +192,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	p.nextToken() // skip ASSIGN
 
 	stmt.Value = p.parseExpression(LOWEST)
+	for _, name := range ast.PatternNames(stmt.Target()) {
+		p.declare(name, stmt.IsConst())
+	}
 
 	if p.expectPeekTokenType(token.SEMICOLON) {
 		p.nextToken()
@@ -140,6 +215,93 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.currToken}
+
+	if !p.expectPeekIs(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeekIs(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeekIs(token.LBRACE) {
+		return nil
+	}
+	p.openScope()
+	stmt.Body = p.parseLoopBody()
+	p.closeScope()
+	if p.expectPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.currToken}
+
+	if !p.expectPeekIs(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeekIs(token.IDENTIFIER) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	if !p.expectPeekIs(token.IN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeekIs(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeekIs(token.LBRACE) {
+		return nil
+	}
+	p.openScope()
+	p.scope.names[stmt.Variable.Value] = false
+	stmt.Body = p.parseLoopBody()
+	p.closeScope()
+	if p.expectPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlockStatement()
+}
+
+// parseLoopControlStatement parse break or continue, which are only allowed inside a loop of the same function
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	var stmt ast.Statement
+	if p.expectCurrTokenType(token.BREAK) {
+		stmt = &ast.BreakStatement{Token: p.currToken}
+	} else {
+		stmt = &ast.ContinueStatement{Token: p.currToken}
+	}
+	if p.loopDepth == 0 {
+		p.addError(&ParseError{
+			Code:  ErrOutsideLoop,
+			Msg:   fmt.Sprintf("%s is not in a loop", p.currToken.Literal),
+			Pos:   p.currToken.Pos,
+			End:   p.currToken.End,
+			Found: p.currToken.Type,
+		})
+		return nil
+	}
+	if p.expectPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.currToken}
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -157,6 +319,9 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		return nil
 	}
 	leftExpr := prefix()
+	if p.panicking {
+		return nil
+	}
 	for !p.expectPeekTokenType(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		// precedence express the current right constraint capacity
 		// p.peekPrecedence() express the current left constraint capacity
@@ -170,6 +335,9 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		}
 		p.nextToken()
 		leftExpr = infix(leftExpr)
+		if p.panicking {
+			return nil
+		}
 	}
 	return leftExpr
 }
@@ -184,14 +352,48 @@ func (p *Parser) parseBoolean() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	expr := &ast.Integer{Token: p.currToken}
 	value, err := strconv.ParseInt(p.currToken.Literal, 0, 64)
+	if err == nil {
+		expr.Value = value
+		return expr
+	}
+	// the literal is too large for int64, keep it as an arbitrary precision integer
+	bigValue, ok := new(big.Int).SetString(p.currToken.Literal, 0)
+	if !ok {
+		p.addError(&ParseError{
+			Code: ErrInvalidInteger,
+			Msg:  fmt.Sprintf("could not parse %q as integer", p.currToken.Literal),
+			Pos:  p.currToken.Pos,
+			End:  p.currToken.End,
+		})
+		return nil
+	}
+	expr.Big = bigValue
+	return expr
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	expr := &ast.FloatLiteral{Token: p.currToken}
+	value, err := strconv.ParseFloat(p.currToken.Literal, 64)
 	if err != nil {
-		p.errors = append(p.errors, fmt.Sprintf("could not parse %q as integer", p.currToken.Literal))
+		p.addError(&ParseError{
+			Code: ErrInvalidFloat,
+			Msg:  fmt.Sprintf("could not parse %q as float", p.currToken.Literal),
+			Pos:  p.currToken.Pos,
+			End:  p.currToken.End,
+		})
 		return nil
 	}
 	expr.Value = value
 	return expr
 }
 
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.currToken,
+		Value: p.currToken.Literal,
+	}
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{
 		Token: p.currToken,
@@ -221,6 +423,32 @@ func (p *Parser) parseInfixExpression(leftExpr ast.Expression) ast.Expression {
 	return expr
 }
 
+// parseAssignExpression the assignment is right associative, a = b = c assigns c to b and then to a
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expr := &ast.AssignExpression{
+		Token:    p.currToken,
+		Target:   target,
+		Operator: p.currToken.Literal,
+	}
+	switch target := target.(type) {
+	case *ast.Identifier:
+		p.checkAssignable(target)
+	case *ast.IndexExpression:
+	default:
+		p.addError(&ParseError{
+			Code:  ErrInvalidAssignment,
+			Msg:   fmt.Sprintf("cannot assign to %s", target.PrintNode()),
+			Pos:   target.Pos(),
+			End:   target.End(),
+			Found: p.currToken.Type,
+		})
+		return nil
+	}
+	p.nextToken()
+	expr.Value = p.parseExpression(ASSIGNMENT - 1)
+	return expr
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
@@ -251,6 +479,20 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expr.Consequence = p.parseBlockStatement()
 	if p.expectPeekTokenType(token.ELSE) {
 		p.nextToken()
+		if p.expectPeekTokenType(token.IF) {
+			// else if (...) {...} is the alternative block holding the nested if expression
+			p.nextToken()
+			ifToken := p.currToken
+			nested := p.parseIfExpression()
+			if nested == nil {
+				return nil
+			}
+			expr.Alternative = &ast.BlockStatement{
+				Token:      ifToken,
+				Statements: []ast.Statement{&ast.ExpressionStatement{Token: ifToken, Expression: nested}},
+			}
+			return expr
+		}
 		if !p.expectPeekIs(token.LBRACE) {
 			return nil
 		}
@@ -259,34 +501,133 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expr
 }
 
+// parseFunctionStatement parse a function declaration, its name is declared in the current scope
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	fnToken := p.currToken
+	p.nextToken()
+	name := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	p.declare(name, false)
+
+	function := p.parseFunction(fnToken)
+	if function == nil {
+		return nil
+	}
+	function.Name = name
+	if p.expectPeekTokenType(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return &ast.FunctionStatement{Function: function}
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
-	expr := &ast.FunctionLiteral{Token: p.currToken}
+	// a nil *ast.FunctionLiteral must not become a non-nil ast.Expression
+	if function := p.parseFunction(p.currToken); function != nil {
+		return function
+	}
+	return nil
+}
+
+// parseMacroLiteral parse a macro, which takes plain names as parameters
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	function := p.parseFunction(p.currToken)
+	if function == nil {
+		return nil
+	}
+	macro := &ast.MacroLiteral{Token: function.Token, Body: function.Body}
+	for i, param := range function.Parameters {
+		ident, ok := param.(*ast.Identifier)
+		if !ok || (i < len(function.Defaults) && function.Defaults[i] != nil) {
+			p.addError(&ParseError{
+				Code: ErrMacroParameter,
+				Msg:  fmt.Sprintf("macro parameter %s must be a plain name", param.PrintNode()),
+				Pos:  param.Pos(),
+				End:  param.End(),
+			})
+			return nil
+		}
+		macro.Parameters = append(macro.Parameters, ident)
+	}
+	if function.Rest != nil {
+		p.addError(&ParseError{
+			Code: ErrMacroParameter,
+			Msg:  fmt.Sprintf("macro parameter ...%s must be a plain name", function.Rest.Value),
+			Pos:  function.Rest.Pos(),
+			End:  function.Rest.End(),
+		})
+		return nil
+	}
+	return macro
+}
+
+// parseFunction parse the parameters and the body following the fn token, the current token is the one before '('
+func (p *Parser) parseFunction(fnToken token.Token) *ast.FunctionLiteral {
+	expr := &ast.FunctionLiteral{Token: fnToken}
 	if !p.expectPeekIs(token.LPAREN) {
 		return nil
 	}
-	expr.Parameters = p.parseFunctionParameters()
+	expr.Parameters, expr.Defaults, expr.Rest = p.parseFunctionParameters()
 	if !p.expectPeekIs(token.LBRACE) {
 		return nil
 	}
+	// the loops enclosing the function literal can't be left by break or continue in its body
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	p.openScope()
+	for _, param := range expr.Parameters {
+		for _, name := range ast.PatternNames(param) {
+			p.scope.names[name.Value] = false
+		}
+	}
+	if expr.Rest != nil {
+		p.scope.names[expr.Rest.Value] = false
+	}
 	expr.Body = p.parseBlockStatement()
+	p.closeScope()
+	p.loopDepth = loopDepth
 	return expr
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	var identifiers []*ast.Identifier
+// parseFunctionParameters parse the parameter list and the default values of the optional trailing parameters,
+// defaults is nil when no parameter has a default value. rest is the final ...rest parameter, if any
+func (p *Parser) parseFunctionParameters() (params []ast.Pattern, defaults []ast.Expression, rest *ast.Identifier) {
+	var hasDefault bool
 	if p.expectPeekTokenType(token.RPAREN) {
 		// case fn()
 		p.nextToken()
-		return identifiers
+		return params, nil, nil
 	}
 
 	p.nextToken()
 	for {
-		identifier := &ast.Identifier{
-			Token: p.currToken,
-			Value: p.currToken.Literal,
+		if p.expectCurrTokenType(token.ELLIPSIS) {
+			// case fn(a, ...rest), the rest parameter must be the last one
+			if !p.expectPeekIs(token.IDENTIFIER) {
+				return nil, nil, nil
+			}
+			rest = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+			break
 		}
-		identifiers = append(identifiers, identifier)
+		param := p.parseBindingPattern()
+		if param == nil {
+			return nil, nil, nil
+		}
+		params = append(params, param)
+		var defaultValue ast.Expression
+		if p.expectPeekTokenType(token.ASSIGN) {
+			// case fn(a, b = 10)
+			p.nextToken()
+			p.nextToken()
+			defaultValue = p.parseExpression(LOWEST)
+			hasDefault = true
+		} else if hasDefault {
+			p.addError(&ParseError{
+				Code: ErrParameterOrder,
+				Msg:  fmt.Sprintf("parameter %s without default value follows parameter with default value", param.PrintNode()),
+				Pos:  param.Pos(),
+				End:  param.End(),
+			})
+		}
+		defaults = append(defaults, defaultValue)
 		if p.expectPeekTokenType(token.COMMA) {
 			p.nextToken()
 			p.nextToken()
@@ -295,27 +636,90 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		break
 	}
 	if !p.expectPeekIs(token.RPAREN) {
-		return nil
+		return nil, nil, nil
+	}
+	if !hasDefault {
+		defaults = nil
 	}
-	return identifiers
+	return params, defaults, rest
 }
 
 func (p *Parser) parseCallFunction(function ast.Expression) ast.Expression {
 	expr := &ast.CallExpression{Token: p.currToken, Function: function}
 	expr.Arguments = p.parseCallArguments()
+	if p.expectCurrTokenType(token.RPAREN) {
+		expr.RParen = p.currToken
+	}
 	return expr
 }
 
 func (p *Parser) parseCallArguments() []ast.Expression {
-	var args []ast.Expression
-	if p.expectPeekTokenType(token.RPAREN) {
+	return p.parseExpressionList(token.RPAREN)
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	expr := &ast.ArrayLiteral{Token: p.currToken}
+	expr.Elements = p.parseExpressionList(token.RBRACKET)
+	if p.expectCurrTokenType(token.RBRACKET) {
+		expr.RBracket = p.currToken
+	}
+	return expr
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	expr := &ast.HashLiteral{Token: p.currToken}
+	for !p.expectPeekTokenType(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+		if !p.expectPeekIs(token.COLON) {
+			return nil
+		}
 		p.nextToken()
-		return args
+		value := p.parseExpression(LOWEST)
+		expr.Pairs = append(expr.Pairs, ast.HashPair{Key: key, Value: value})
+		if p.expectPeekTokenType(token.COMMA) {
+			p.nextToken()
+		} else if !p.expectPeekTokenType(token.RBRACE) {
+			// without a ',' the pair must be the last one
+			p.CollectPeekTokenTypeError(token.RBRACE)
+			return nil
+		}
+	}
+	p.nextToken()
+	expr.RBrace = p.currToken
+	return expr
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expr := &ast.IndexExpression{Token: p.currToken, Left: left}
+	p.nextToken()
+	expr.Index = p.parseExpression(LOWEST)
+	if !p.expectPeekIs(token.RBRACKET) {
+		return nil
+	}
+	expr.RBracket = p.currToken
+	return expr
+}
+
+// parseExpressionList parse comma separated expressions until the end token, e.g. call arguments or array elements.
+// an element can be spread with ...
+func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
+	var list []ast.Expression
+	if p.expectPeekTokenType(end) {
+		p.nextToken()
+		return list
 	}
 
 	p.nextToken()
 	for {
-		args = append(args, p.parseExpression(LOWEST))
+		if p.expectCurrTokenType(token.ELLIPSIS) {
+			spread := &ast.SpreadExpression{Token: p.currToken}
+			p.nextToken()
+			spread.Value = p.parseExpression(LOWEST)
+			list = append(list, spread)
+		} else {
+			list = append(list, p.parseExpression(LOWEST))
+		}
 		if p.expectPeekTokenType(token.COMMA) {
 			p.nextToken()
 			p.nextToken()
@@ -323,10 +727,10 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 		}
 		break
 	}
-	if !p.expectPeekIs(token.RPAREN) {
+	if !p.expectPeekIs(end) {
 		return nil
 	}
-	return args
+	return list
 }
 
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
@@ -336,27 +740,118 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 	p.nextToken()
 	for !p.expectCurrTokenType(token.RBRACE) && !p.expectCurrTokenType(token.EOF) {
+		start := p.statementStart()
 		stmt := p.parseStatement()
+		if p.panicking {
+			p.synchronize(true, start)
+			continue
+		}
 		if stmt != nil {
 			bStmt.Statements = append(bStmt.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	if p.expectCurrTokenType(token.RBRACE) {
+		bStmt.RBrace = p.currToken
+	}
 	return bStmt
 }
 
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
 
 func (p *Parser) CollectPrefixParseFnError(t token.Type) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	if t == token.ILLEGAL {
+		p.addError(&ParseError{
+			Code:  ErrIllegalToken,
+			Msg:   fmt.Sprintf("illegal token %q", p.currToken.Literal),
+			Pos:   p.currToken.Pos,
+			End:   p.currToken.End,
+			Found: t,
+		})
+		return
+	}
+	p.addError(&ParseError{
+		Code:  ErrNoPrefixParseFn,
+		Msg:   fmt.Sprintf("no prefix parse function for %s found", t),
+		Pos:   p.currToken.Pos,
+		End:   p.currToken.End,
+		Found: t,
+	})
 }
 
 func (p *Parser) CollectPeekTokenTypeError(expectedType token.Type) {
-	msg := fmt.Sprintf("expected next token type to be %s, got %s instead", expectedType, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(&ParseError{
+		Code:     ErrUnexpectedToken,
+		Msg:      fmt.Sprintf("expected next token type to be %s, got %s instead", expectedType, p.peekToken.Type),
+		Pos:      p.peekToken.Pos,
+		End:      p.peekToken.End,
+		Expected: expectedType,
+		Found:    p.peekToken.Type,
+	})
+}
+
+// addError record the error and enter panic mode, the errors following it are
+// the consequences of the same mistake and are discarded until the parser synchronizes
+func (p *Parser) addError(err *ParseError) {
+	if p.panicking {
+		return
+	}
+	p.errors = append(p.errors, err)
+	p.panicking = true
+}
+
+// statementStart return the brace depth before the current token, which starts a statement
+func (p *Parser) statementStart() int {
+	return p.braceDepth - braceDelta(p.currToken.Type)
+}
+
+// synchronize skip tokens until a point where a new statement can start: after a ';', before a statement keyword
+// or at the '}' closing the enclosing block, which is left for the block to consume. the braces opened by the broken
+// statement are skipped with their content, start is the brace depth before the statement
+func (p *Parser) synchronize(inBlock bool, start int) {
+	p.panicking = false
+	for !p.expectCurrTokenType(token.EOF) {
+		depth := p.braceDepth - start
+		switch {
+		case depth < 0:
+			// the '}' closing the enclosing block
+			if !inBlock {
+				p.nextToken()
+			}
+			return
+		case depth > 0:
+		case p.expectCurrTokenType(token.SEMICOLON):
+			p.nextToken()
+			return
+		case isStatementKeyword(p.peekToken.Type):
+			p.nextToken()
+			return
+		}
+		p.nextToken()
+	}
+}
+
+func braceDelta(t token.Type) int {
+	switch t {
+	case token.LBRACE:
+		return 1
+	case token.RBRACE:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// isStatementKeyword judge the token type is or isn't a keyword which can only start a statement
+func isStatementKeyword(t token.Type) bool {
+	switch t {
+	case token.LET, token.CONST, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	default:
+		return false
+	}
 }
 
 func (p *Parser) expectPeekIs(t token.Type) bool {
@@ -378,22 +873,21 @@ func (p *Parser) expectCurrTokenType(t token.Type) bool {
 }
 
 func (p *Parser) peekPrecedence() int {
-	if precedence, ok := precedences[p.peekToken.Type]; ok {
-		return precedence
-	}
-	return LOWEST
+	return Precedence(p.peekToken.Type)
 }
 
 func (p *Parser) currPrecedence() int {
-	if precedence, ok := precedences[p.currToken.Type]; ok {
-		return precedence
-	}
-	return LOWEST
+	return Precedence(p.currToken.Type)
 }
 
 func (p *Parser) nextToken() {
 	p.currToken = p.peekToken
+	p.braceDepth += braceDelta(p.currToken.Type)
 	p.peekToken = p.l.ReadToken()
+	for p.peekToken.Type == token.COMMENT {
+		p.comments = append(p.comments, &ast.Comment{Token: p.peekToken})
+		p.peekToken = p.l.ReadToken()
+	}
 }
 
 func (p *Parser) registerPrefix(t token.Type, fn prefixParseFn) {
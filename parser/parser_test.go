@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"github.com/GzzyZm/interpreter/ast"
 	"github.com/GzzyZm/interpreter/lexer"
+	"github.com/GzzyZm/interpreter/token"
 	"testing"
 )
 
@@ -139,6 +141,73 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "123456789012345678901234567890;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.Integer)
+	if !ok {
+		t.Fatalf("exp not *ast.Integer. got=%T", stmt.Expression)
+	}
+	if literal.Big == nil {
+		t.Fatalf("literal.Big is nil")
+	}
+	if literal.Big.String() != "123456789012345678901234567890" {
+		t.Errorf("literal.Big not %s. got=%s", "123456789012345678901234567890", literal.Big)
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e-9;", 1e-9},
+		{"2.5E+3;", 2500},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello world" {
+		t.Errorf("literal.Value not %q. got=%q", "hello world", literal.Value)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -195,10 +264,15 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 - 5;", 5, "-", 5},
 		{"5 * 5;", 5, "*", 5},
 		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
 		{"5 > 5;", 5, ">", 5},
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"true && false;", true, "&&", false},
+		{"true || false;", true, "||", false},
 		{"foobar + barfoo;", "foobar", "+", "barfoo"},
 		{"foobar - barfoo;", "foobar", "-", "barfoo"},
 		{"foobar * barfoo;", "foobar", "*", "barfoo"},
@@ -269,6 +343,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a + b / c",
 			"(a + (b / c))",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
 		{
 			"a + b * c + d / e - f",
 			"(((a + (b * c)) + (d / e)) - f)",
@@ -341,6 +419,30 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"fns[0](1)",
+			"(fns[0])(1)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a <= b == c >= d && !e",
+			"(((a <= b) == (c >= d)) && (!e))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -578,6 +680,38 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultParameterParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b = 10) { a + b }", "fn(a, b = 10) (a + b)"},
+		{"fn(a = 1, b = a * 2) {}", "fn(a = 1, b = (a * 2)) "},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	l := lexer.New("fn(a = 1, b) {}")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) != 1 {
+		t.Fatalf("expected 1 parser error. got=%q", p.Errors())
+	}
+	expected := "parameter b without default value follows parameter with default value"
+	if p.Errors()[0].Msg != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, p.Errors()[0].Msg)
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -670,6 +804,824 @@ func TestCallExpressionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionStatementParsing(t *testing.T) {
+	input := `fn add(a, b = 1) { a + b } fn(x) { x }(1); let f = fn() { 0 };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Function.Name, "add")
+	if len(stmt.Function.Parameters) != 2 {
+		t.Errorf("wrong number of parameters. got=%d", len(stmt.Function.Parameters))
+	}
+	if stmt.PrintNode() != "fn add(a, b = 1) (a + b)" {
+		t.Errorf("stmt.PrintNode() wrong. got=%q", stmt.PrintNode())
+	}
+	if _, ok := program.Statements[1].(*ast.ExpressionStatement); !ok {
+		t.Errorf("program.Statements[1] is not ast.ExpressionStatement. got=%T", program.Statements[1])
+	}
+	if fn := program.Statements[2].(*ast.LetStatement).Value.(*ast.FunctionLiteral); fn.Name != nil {
+		t.Errorf("anonymous function has a name. got=%q", fn.Name.Value)
+	}
+
+	p = New(lexer.New("fn f() { 1 }; f()"))
+	program = p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	p = New(lexer.New("const f = 1; fn f() { }"))
+	p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 1 || errs[0].Msg != "cannot redeclare constant f" {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestVariadicParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(first, ...rest) { rest }", "fn(first, ...rest) rest"},
+		{"fn(a, b = 1, ...rest) { }", "fn(a, b = 1, ...rest) "},
+		{"fn(...all) { }", "fn(...all) "},
+		{"f(...args)", "f(...args)"},
+		{"f(1, ...a + b, ...[2])", "f(1, ...(a + b), ...[2])"},
+		{"[0, ...xs]", "[0, ...xs]"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	program := New(lexer.New("fn(a, ...rest) { }")).ParseProgram()
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Parameters) != 1 || function.Rest == nil {
+		t.Fatalf("wrong parameters. got=%d, rest=%v", len(function.Parameters), function.Rest)
+	}
+	testIdentifier(t, function.Rest, "rest")
+
+	errs := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(...rest, a) { }", "expected next token type to be ), got , instead"},
+		{"fn(...) { }", "expected next token type to be IDENTIFIER, got ) instead"},
+		{"fn(...rest = 1) { }", "expected next token type to be ), got = instead"},
+	}
+	for _, tt := range errs {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errors := p.Errors(); len(errors) == 0 || errors[0].Msg != tt.expected {
+			t.Errorf("%q: wrong errors. want=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestDestructuringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...rest] = arr;", "let [a, b, ...rest] = arr;"},
+		{"let {name, age} = person;", "let {name, age} = person;"},
+		{"const [x, [y, z], {w}] = v;", "const [x, [y, z], {w}] = v;"},
+		{"let [] = [];", "let [] = [];"},
+		{"let {} = {};", "let {} = {};"},
+		{"fn([a, b], {name}, c = 1, ...rest) { a }", "fn([a, b], {name}, c = 1, ...rest) a"},
+		{"fn({x} = {\"x\": 1}) { x }", "fn({x} = {x: 1}) x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	program := New(lexer.New("let [a, {b}, ...c] = v;")).ParseProgram()
+	stmt := program.Statements[0].(*ast.LetStatement)
+	if stmt.Name != nil {
+		t.Errorf("destructuring statement has a name. got=%q", stmt.Name.Value)
+	}
+	pattern, ok := stmt.Pattern.(*ast.ArrayPattern)
+	if !ok {
+		t.Fatalf("stmt.Pattern is not ast.ArrayPattern. got=%T", stmt.Pattern)
+	}
+	if len(pattern.Elements) != 2 || pattern.Rest == nil {
+		t.Fatalf("wrong array pattern. got=%q", pattern.PrintNode())
+	}
+	var names []string
+	for _, name := range ast.PatternNames(pattern) {
+		names = append(names, name.Value)
+	}
+	if fmt.Sprint(names) != "[a b c]" {
+		t.Errorf("wrong pattern names. got=%v", names)
+	}
+
+	errs := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, 1] = v;", "expected a name, an array pattern or a hash pattern, got INT"},
+		{"let [...a, b] = v;", "expected next token type to be ], got , instead"},
+		{"let {a, [b]} = v;", "expected next token type to be IDENTIFIER, got [ instead"},
+		{"fn(1) { }", "expected a name, an array pattern or a hash pattern, got INT"},
+		{"const [a, b] = v; a = 1;", "cannot assign to constant a"},
+		{"let [a, b] = v; const {b} = w;", "cannot redeclare b as a constant"},
+	}
+	for _, tt := range errs {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if errors := p.Errors(); len(errors) == 0 || errors[0].Msg != tt.expected {
+			t.Errorf("%q: wrong errors. want=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < 0) { -1 } else if (x == 0) { 0 } else if (x < 10) { 1 } else { 2 }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	expr, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	for depth := 0; depth < 2; depth++ {
+		if expr.Alternative == nil || len(expr.Alternative.Statements) != 1 {
+			t.Fatalf("depth %d: alternative is not a single statement. got=%+v", depth, expr.Alternative)
+		}
+		nested, ok := expr.Alternative.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+		if !ok {
+			t.Fatalf("depth %d: alternative is not an if expression", depth)
+		}
+		expr = nested
+	}
+	if expr.Alternative == nil || expr.Alternative.PrintNode() != "2" {
+		t.Errorf("last alternative wrong. got=%+v", expr.Alternative)
+	}
+	if program.End().Offset != len(input) {
+		t.Errorf("program end wrong. want=%d, got=%d", len(input), program.End().Offset)
+	}
+}
+
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1, 2 => \"small\", n if n > 10 => { n * 2 }, _ => 0 }",
+			"match x { 1, 2 => small, n if (n > 10) => (n * 2), _ => 0 }"},
+		{"match (f(x)) { -1 => a; true => { b } \"s\" => c }", "match f(x) { (-1) => a, true => b, s => c }"},
+		{"match (x) { }", "match x {  }"},
+		{"match (x) { 1.5 => { {\"k\": 1} } }", "match x { 1.5 => {k: 1} }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	errs := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { a + 1 => 1 }; let y = 1;", "invalid pattern: (a + 1)"},
+		{"match (x) { [1] => 1 }", "invalid pattern: [1]"},
+		{"match (x) { 1 2 }", "expected next token type to be =>, got INT instead"},
+		{"match (x) { 1 => 2 3 => 4 }", "expected next token type to be ,, got INT instead"},
+		{"match (x) { 1 => f (2) => 4 }", "expected next token type to be ,, got => instead"},
+		{"match (x) { 1 => { 2 } 3 => 4; _ => 5 }", ""},
+		{"const n = 1; match (x) { n => { n = 2 } }", ""},
+	}
+	for _, tt := range errs {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if tt.expected == "" {
+			if len(errors) != 0 {
+				t.Errorf("%q: unexpected errors %q", tt.input, errors)
+			}
+			continue
+		}
+		if len(errors) == 0 || errors[0].Msg != tt.expected {
+			t.Errorf("%q: wrong errors. want=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	program := New(lexer.New("const limit = 10; let x = limit;")).ParseProgram()
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.LetStatement. got=%T", program.Statements[0])
+	}
+	if !stmt.IsConst() || stmt.TokenLiteral() != "const" {
+		t.Errorf("stmt is not a constant declaration. got=%q", stmt.PrintNode())
+	}
+	if program.Statements[1].(*ast.LetStatement).IsConst() {
+		t.Errorf("let statement is a constant declaration")
+	}
+
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"const a = 1; a = 2;", "cannot assign to constant a"},
+		{"const a = 1; a += 2;", "cannot assign to constant a"},
+		{"const a = 1; const a = 2;", "cannot redeclare constant a"},
+		{"const a = 1; let a = 2;", "cannot redeclare constant a"},
+		{"let a = 1; const a = 2;", "cannot redeclare a as a constant"},
+		{"const a = 1; let f = fn() { a = 2 };", "cannot assign to constant a"},
+		{"const a = 1; while (true) { a = 2 }", "cannot assign to constant a"},
+		{"const a = 1; let f = fn() { let a = 2; a = 3 };", ""},
+		{"const a = 1; let f = fn(a) { a = 3 };", ""},
+		{"const a = 1; for (a in [1]) { a = 3 }", ""},
+		{"while (true) { const c = 1; }", ""},
+		{"let f = fn() { b = 1 }; const b = 1;", ""},
+		{"const a = [1]; a[0] = 2;", ""},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errs := p.Errors()
+		if tt.expectedError == "" {
+			if len(errs) != 0 {
+				t.Errorf("%q: unexpected errors %q", tt.input, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%q: wrong number of errors. got=%q", tt.input, errs)
+			continue
+		}
+		if errs[0].Code != ErrConstant || errs[0].Msg != tt.expectedError {
+			t.Errorf("%q: wrong error. want=%q, got=%s %q", tt.input, tt.expectedError, errs[0].Code, errs[0].Msg)
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "(x = 5)"},
+		{"x = y = 1 + 2;", "(x = (y = (1 + 2)))"},
+		{"x += y * 2;", "(x += (y * 2))"},
+		{"x -= 1; x *= 2; x /= 3", "(x -= 1)(x *= 2)(x /= 3)"},
+		{"a[i + 1] = b || c;", "((a[(i + 1)]) = (b || c))"},
+		{"h[\"k\"] += f(1)[0];", "((h[k]) += (f(1)[0]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestLoopStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x; }", "while (x < 10) x"},
+		{"while (true) { break; continue }", "while true break;continue;"},
+		{"for (x in [1, 2]) { puts(x) }", "for x in [1, 2] puts(x)"},
+		{"for (k in range(3)) { if (k == 1) { continue; } k }", "for k in range(3) if (k == 1) continue;k"},
+		{"while (a) { for (b in c) { break; } break; }", "while a for b in c break;break;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.PrintNode(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{"while (c) { c }; x", "for (x in a) { x }; y"} {
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 2 {
+			t.Fatalf("%q: program.Statements does not contain 2 statements. got=%d", input, len(program.Statements))
+		}
+	}
+
+	program := New(lexer.New("for (item in items) {}")).ParseProgram()
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Variable, "item")
+	testIdentifier(t, stmt.Iterable, "items")
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	program := New(lexer.New(input)).ParseProgram()
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T", stmt.Expression)
+	}
+	if len(macro.Parameters) != 2 {
+		t.Fatalf("macro literal parameters wrong. want 2, got=%d", len(macro.Parameters))
+	}
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+	if actual := macro.PrintNode(); actual != "macro(x, y) (x + y)" {
+		t.Errorf("macro literal printed wrong. got=%q", actual)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"macro(a, b = 1) { a }", "macro parameter b must be a plain name"},
+		{"macro([a, b]) { a }", "macro parameter [a, b] must be a plain name"},
+		{"macro(a, ...rest) { a }", "macro parameter ...rest must be a plain name"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errs := p.Errors()
+		if len(errs) != 1 {
+			t.Errorf("%q: expected 1 error. got=%q", tt.input, errs)
+			continue
+		}
+		if errs[0].Code != ErrMacroParameter || errs[0].Msg != tt.expected {
+			t.Errorf("%q: wrong error. want=%s %q, got=%s %q", tt.input, ErrMacroParameter, tt.expected, errs[0].Code, errs[0].Msg)
+		}
+	}
+}
+
+func TestCommentsAreIgnored(t *testing.T) {
+	input := `// add two numbers
+let add = fn(x, y) { /* sum */ x + y; }; // done`
+
+	l := lexer.New(input)
+	l.SetMode(lexer.ScanComments)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := "let add = fn(x, y) (x + y);"
+	if actual := program.PrintNode(); actual != expected {
+		t.Errorf("expected=%q, got=%q", expected, actual)
+	}
+
+	// the comments are kept aside of the statements
+	comments := []struct {
+		text string
+		pos  string
+	}{
+		{"// add two numbers", "1:1"},
+		{"/* sum */", "2:22"},
+		{"// done", "2:42"},
+	}
+	if len(program.Comments) != len(comments) {
+		t.Fatalf("program.Comments wrong. want %d comments, got=%d", len(comments), len(program.Comments))
+	}
+	for i, tt := range comments {
+		comment := program.Comments[i]
+		if comment.Token.Literal != tt.text || comment.Pos().String() != tt.pos {
+			t.Errorf("comments[%d] wrong. want=%q at %s, got=%q at %s", i, tt.text, tt.pos, comment.Token.Literal, comment.Pos())
+		}
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string
+	}{
+		{
+			"let = 5; let y = 10;",
+			[]string{"expected next token type to be IDENTIFIER, got = instead"},
+			"let y = 10;",
+		},
+		{
+			"let x 5 * 3 + 2; let y = 10; y;",
+			[]string{"expected next token type to be =, got INT instead"},
+			"let y = 10;y",
+		},
+		{
+			"let a = add(1, 2; let b = 3; return b",
+			[]string{"expected next token type to be ), got ; instead"},
+			"let b = 3;return b;",
+		},
+		{
+			"if (x { 1 } let c = 3; let d = ; d",
+			[]string{
+				"expected next token type to be ), got { instead",
+				"no prefix parse function for ; found",
+			},
+			"let c = 3;d",
+		},
+		{
+			"let f = fn(x) { x + ; x * 2 }; f(2)",
+			[]string{"no prefix parse function for ; found"},
+			"let f = fn(x) (x * 2);f(2)",
+		},
+		{
+			"let f = fn(x) { let = 1 }; let g = 2;",
+			[]string{"expected next token type to be IDENTIFIER, got = instead"},
+			"let f = fn(x) ;let g = 2;",
+		},
+		{
+			"} let h = 1;",
+			[]string{"no prefix parse function for } found"},
+			"let h = 1;",
+		},
+		{
+			"1 + x = 2; let j = 1;",
+			[]string{"cannot assign to (1 + x)"},
+			"let j = 1;",
+		},
+		{
+			"break; let i = 1;",
+			[]string{"break is not in a loop"},
+			"let i = 1;",
+		},
+		{
+			"while (true) { let f = fn() { continue; }; break; }",
+			[]string{"continue is not in a loop"},
+			"while true let f = fn() ;break;",
+		},
+		{
+			`let h = {1: 2 3: 4}; let i = 3;`,
+			[]string{"expected next token type to be }, got INT instead"},
+			"let i = 3;",
+		},
+		{
+			`let h = {1: 2`,
+			[]string{"expected next token type to be }, got EOF instead"},
+			"",
+		},
+		{
+			`let a = {"k" 1}; let b = 2;`,
+			[]string{"expected next token type to be :, got INT instead"},
+			"let b = 2;",
+		},
+		{
+			`fn f() { let a = {"k" 1}; let b = 2; } let c = 3;`,
+			[]string{"expected next token type to be :, got INT instead"},
+			"fn f() let b = 2;let c = 3;",
+		},
+		{
+			`let g = fn() { if (x { 1 } }; let d = 4;`,
+			[]string{"expected next token type to be ), got { instead"},
+			"let g = fn() ;let d = 4;",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("%q: wrong number of errors. want=%q, got=%q", tt.input, tt.expectedErrors, errors)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errors[i].Msg != msg {
+				t.Errorf("%q: error %d wrong. want=%q, got=%q", tt.input, i, msg, errors[i].Msg)
+			}
+		}
+
+		if actual := program.PrintNode(); actual != tt.expectedStatements {
+			t.Errorf("%q: partial program wrong. want=%q, got=%q", tt.input, tt.expectedStatements, actual)
+		}
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	input := `let x = 1;
+let = 2;
+let y = "open`
+
+	l := lexer.NewFile("main.mk", input)
+	p := New(l)
+	p.ParseProgram()
+
+	errs := p.Errors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 parser errors. got=%q", errs)
+	}
+
+	tests := []struct {
+		code     ErrorCode
+		pos      string
+		endCol   int
+		expected token.Type
+		found    token.Type
+		message  string
+	}{
+		{ErrUnexpectedToken, "main.mk:2:5", 6, token.IDENTIFIER, token.ASSIGN,
+			"main.mk:2:5: expected next token type to be IDENTIFIER, got = instead"},
+		{ErrIllegalToken, "main.mk:3:9", 14, "", token.ILLEGAL,
+			`main.mk:3:9: illegal token "\"open"`},
+	}
+
+	for i, tt := range tests {
+		err := errs[i]
+		if err.Code != tt.code {
+			t.Errorf("errors[%d] - code wrong. want=%s, got=%s", i, tt.code, err.Code)
+		}
+		if err.Pos.String() != tt.pos {
+			t.Errorf("errors[%d] - position wrong. want=%s, got=%s", i, tt.pos, err.Pos)
+		}
+		if err.End.Column != tt.endCol {
+			t.Errorf("errors[%d] - end column wrong. want=%d, got=%d", i, tt.endCol, err.End.Column)
+		}
+		if err.Expected != tt.expected || err.Found != tt.found {
+			t.Errorf("errors[%d] - token types wrong. want=%s/%s, got=%s/%s",
+				i, tt.expected, tt.found, err.Expected, err.Found)
+		}
+		if err.Error() != tt.message {
+			t.Errorf("errors[%d] - message wrong. want=%q, got=%q", i, tt.message, err.Error())
+		}
+	}
+
+	err := errs.Err()
+	if err == nil {
+		t.Fatalf("errs.Err() returned nil")
+	}
+	if err.Error() != tests[0].message+"\n"+tests[1].message {
+		t.Errorf("aggregate message wrong. got=%q", err.Error())
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr != errs[0] {
+		t.Errorf("errors.As didn't find the first ParseError")
+	}
+	if (ErrorList{}).Err() != nil {
+		t.Errorf("empty ErrorList.Err() is not nil")
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(x, y) {
+  x + y;
+};
+add(1, 2 * 3);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		node     ast.Node
+		startPos string
+		endPos   string
+	}{
+		{program, "1:1", "4:14"},
+		{program.Statements[0], "1:1", "3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body, "1:20", "3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0], "2:3", "2:8"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression, "4:1", "4:14"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], "4:8", "4:13"},
+	}
+
+	for i, tt := range tests {
+		if pos := tt.node.Pos().String(); pos != tt.startPos {
+			t.Errorf("tests[%d] - start position wrong. want=%s, got=%s", i, tt.startPos, pos)
+		}
+		if end := tt.node.End().String(); end != tt.endPos {
+			t.Errorf("tests[%d] - end position wrong. want=%s, got=%s", i, tt.endPos, end)
+		}
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 3 {
+		t.Fatalf("len(array.Elements) not 3. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(t, array.Elements[0], 1)
+	testInfixExpression(t, array.Elements[1], 2, "*", 2)
+	testInfixExpression(t, array.Elements[2], 3, "+", 3)
+}
+
+func TestParsingEmptyArrayLiterals(t *testing.T) {
+	input := "[]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		t.Fatalf("exp not ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 0 {
+		t.Errorf("len(array.Elements) not 0. got=%d", len(array.Elements))
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.IndexExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, indexExp.Left, "myArray") {
+		return
+	}
+
+	if !testInfixExpression(t, indexExp.Index, 1, "+", 1) {
+		return
+	}
+}
+
+func TestParsingHashLiteralsStringKeys(t *testing.T) {
+	input := `{"one": 1, "two": 2, "three": 3}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	expected := []struct {
+		key   string
+		value int64
+	}{
+		{"one", 1},
+		{"two", 2},
+		{"three", 3},
+	}
+
+	if len(hash.Pairs) != len(expected) {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
+		if !ok {
+			t.Errorf("key is not ast.StringLiteral. got=%T", pair.Key)
+			continue
+		}
+		if literal.Value != expected[i].key {
+			t.Errorf("key %d wrong. want=%q, got=%q", i, expected[i].key, literal.Value)
+		}
+		testIntegerLiteral(t, pair.Value, expected[i].value)
+	}
+}
+
+func TestParsingEmptyHashLiteral(t *testing.T) {
+	input := "{}"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 0 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+}
+
+func TestParsingHashLiteralsWithExpressions(t *testing.T) {
+	input := `{"one": 0 + 1, true: 10 - 8, 3: 15 / 5}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(hash.Pairs) != 3 {
+		t.Fatalf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+
+	testInfixExpression(t, hash.Pairs[0].Value, 0, "+", 1)
+	testLiteralExpression(t, hash.Pairs[1].Key, true)
+	testInfixExpression(t, hash.Pairs[1].Value, 10, "-", 8)
+	testLiteralExpression(t, hash.Pairs[2].Key, 3)
+	testInfixExpression(t, hash.Pairs[2].Value, 15, "/", 5)
+}
+
+func TestParsingHashLiteralInBlock(t *testing.T) {
+	input := `if (true) { {"a": 1} } else { {} }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp := stmt.Expression.(*ast.IfExpression)
+	consequence := exp.Consequence.Statements[0].(*ast.ExpressionStatement)
+	if _, ok := consequence.Expression.(*ast.HashLiteral); !ok {
+		t.Fatalf("consequence is not ast.HashLiteral. got=%T", consequence.Expression)
+	}
+	alternative := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if _, ok := alternative.Expression.(*ast.HashLiteral); !ok {
+		t.Fatalf("alternative is not ast.HashLiteral. got=%T", alternative.Expression)
+	}
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let'. got=%q", s.TokenLiteral())
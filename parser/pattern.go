@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// parseBindingPattern parse the target of a let statement or a parameter: a name, [a, b, ...rest] or {a, b}.
+// a nil ast.Pattern is returned on error, never a nil pointer of a pattern type
+func (p *Parser) parseBindingPattern() ast.Pattern {
+	switch p.currToken.Type {
+	case token.IDENTIFIER:
+		return &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+	case token.LBRACKET:
+		if pattern := p.parseArrayPattern(); pattern != nil {
+			return pattern
+		}
+		return nil
+	case token.LBRACE:
+		if pattern := p.parseHashPattern(); pattern != nil {
+			return pattern
+		}
+		return nil
+	default:
+		p.addError(&ParseError{
+			Code:  ErrInvalidPattern,
+			Msg:   fmt.Sprintf("expected a name, an array pattern or a hash pattern, got %s", p.currToken.Type),
+			Pos:   p.currToken.Pos,
+			End:   p.currToken.End,
+			Found: p.currToken.Type,
+		})
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayPattern() *ast.ArrayPattern {
+	pattern := &ast.ArrayPattern{Token: p.currToken}
+	for !p.expectPeekTokenType(token.RBRACKET) {
+		p.nextToken()
+		if p.expectCurrTokenType(token.ELLIPSIS) {
+			// the rest element must be the last one
+			if !p.expectPeekIs(token.IDENTIFIER) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+			break
+		}
+		element := p.parseBindingPattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+		if !p.expectPeekTokenType(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeekIs(token.RBRACKET) {
+		return nil
+	}
+	pattern.RBracket = p.currToken
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() *ast.HashPattern {
+	pattern := &ast.HashPattern{Token: p.currToken}
+	for !p.expectPeekTokenType(token.RBRACE) {
+		if !p.expectPeekIs(token.IDENTIFIER) {
+			return nil
+		}
+		pattern.Keys = append(pattern.Keys, &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal})
+		if !p.expectPeekTokenType(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeekIs(token.RBRACE) {
+		return nil
+	}
+	pattern.RBrace = p.currToken
+	return pattern
+}
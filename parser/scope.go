@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/GzzyZm/interpreter/ast"
+)
+
+// scope the names declared by let and const in one environment of the program, the value is true for constants.
+// the names which aren't declared in the parsed source, e.g. the bindings of earlier repl inputs,
+// are unknown here and left to the runtime checks of object.Environment
+type scope struct {
+	names map[string]bool
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{names: make(map[string]bool), outer: outer}
+}
+
+// lookup find the innermost declaration of name
+func (s *scope) lookup(name string) (constant bool, ok bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if constant, ok = sc.names[name]; ok {
+			return constant, true
+		}
+	}
+	return false, false
+}
+
+// openScope start the scope of a function body or a loop iteration, which get their own environment at runtime
+func (p *Parser) openScope() {
+	p.scope = newScope(p.scope)
+}
+
+func (p *Parser) closeScope() {
+	p.scope = p.scope.outer
+}
+
+// declare record the name bound by a let, const or function declaration,
+// a constant can't share its scope with another declaration of its name
+func (p *Parser) declare(ident *ast.Identifier, isConst bool) {
+	name := ident.Value
+	if constant, ok := p.scope.names[name]; ok && (constant || isConst) {
+		msg := fmt.Sprintf("cannot redeclare constant %s", name)
+		if !constant {
+			msg = fmt.Sprintf("cannot redeclare %s as a constant", name)
+		}
+		p.addError(&ParseError{
+			Code:  ErrConstant,
+			Msg:   msg,
+			Pos:   ident.Pos(),
+			End:   ident.End(),
+			Found: ident.Token.Type,
+		})
+		return
+	}
+	p.scope.names[name] = isConst
+}
+
+// checkAssignable report the assignment to a name which is declared as a constant
+func (p *Parser) checkAssignable(target *ast.Identifier) {
+	if constant, _ := p.scope.lookup(target.Value); constant {
+		p.addError(&ParseError{
+			Code:  ErrConstant,
+			Msg:   fmt.Sprintf("cannot assign to constant %s", target.Value),
+			Pos:   target.Pos(),
+			End:   target.End(),
+			Found: target.Token.Type,
+		})
+	}
+}
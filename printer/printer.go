@@ -0,0 +1,566 @@
+package printer
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/lexer"
+	"github.com/GzzyZm/interpreter/parser"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+// indentation the text indenting the statements of a block by one level
+const indentation = "    "
+
+// primary the precedence of the expressions which never need parentheses, e.g. literals and identifiers
+const primary = parser.INDEX + 1
+
+// Format parse the source and return it in canonical form, the comments are kept.
+// the error is the parser.ErrorList of the source when it can't be parsed
+func Format(filename string, src []byte) ([]byte, error) {
+	l := lexer.NewFile(filename, string(src))
+	l.SetMode(lexer.ScanComments)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if err := p.Errors().Err(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := Fprint(&out, program); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Fprint write the canonical source of the node to output. the comments of a program are printed
+// before the statement following them, or at the end of the line of the statement they follow
+func Fprint(output io.Writer, node ast.Node) error {
+	p := &printer{}
+	if program, ok := node.(*ast.Program); ok {
+		p.comments = program.Comments
+	}
+	p.node(node)
+	if _, ok := node.(*ast.Program); ok && p.out.Len() > 0 {
+		p.out.WriteByte('\n')
+	}
+	_, err := output.Write(p.out.Bytes())
+	return err
+}
+
+type printer struct {
+	out      bytes.Buffer
+	indent   int            // the number of blocks enclosing the current line
+	comments []*ast.Comment // the comments not printed yet, in source order
+	lastLine int            // the source line of the last statement or comment printed, 0 if unknown
+}
+
+func (p *printer) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		p.statements(n.Statements, token.Position{})
+		for len(p.comments) > 0 {
+			p.comment(p.comments[0])
+		}
+	case *ast.BlockStatement:
+		p.block(n)
+	case *ast.Comment:
+		p.print(commentText(n))
+	case ast.Statement:
+		p.statement(n, nil)
+	case ast.Expression:
+		p.expression(n, parser.LOWEST)
+	}
+}
+
+func (p *printer) print(s string) {
+	p.out.WriteString(s)
+}
+
+// startLine begin the line of a statement, a comment or a match arm. the blank lines separating it
+// from the previous one in the source are kept, reduced to one
+func (p *printer) startLine(line int) {
+	if p.out.Len() > 0 {
+		p.out.WriteByte('\n')
+		if p.lastLine > 0 && line > p.lastLine+1 {
+			p.out.WriteByte('\n')
+		}
+	}
+	p.print(strings.Repeat(indentation, p.indent))
+}
+
+// statements print the statements on their own lines, end is the position of the '}' closing them
+func (p *printer) statements(list []ast.Statement, end token.Position) {
+	for i, stmt := range list {
+		p.commentsBefore(stmt.Pos())
+		p.startLine(stmt.Pos().Line)
+		var next ast.Statement
+		limit := end
+		if i+1 < len(list) {
+			next = list[i+1]
+			limit = next.Pos()
+		}
+		p.statement(stmt, next)
+		p.lastLine = stmt.End().Line
+		p.trailingComments(stmt.End(), limit)
+	}
+	p.commentsBefore(end)
+}
+
+// commentsBefore print the comments preceding pos on their own lines
+func (p *printer) commentsBefore(pos token.Position) {
+	for p.hasCommentBefore(pos) {
+		p.comment(p.comments[0])
+	}
+}
+
+func (p *printer) hasCommentBefore(pos token.Position) bool {
+	return pos.IsValid() && len(p.comments) > 0 && p.comments[0].Pos().Offset < pos.Offset
+}
+
+func (p *printer) comment(comment *ast.Comment) {
+	p.startLine(comment.Pos().Line)
+	p.print(commentText(comment))
+	p.lastLine = comment.End().Line
+	p.comments = p.comments[1:]
+}
+
+// trailingComments print the comments starting on the line of end at the end of the current line,
+// limit is the position of the next statement or of the '}' closing the statements
+func (p *printer) trailingComments(end token.Position, limit token.Position) {
+	for end.IsValid() && len(p.comments) > 0 {
+		comment := p.comments[0]
+		if comment.Pos().Line > end.Line || (limit.IsValid() && comment.Pos().Offset >= limit.Offset) {
+			return
+		}
+		p.print(" " + commentText(comment))
+		// a comment inside the statement ends before it, the last line printed doesn't move back
+		if line := comment.End().Line; line > p.lastLine {
+			p.lastLine = line
+		}
+		p.comments = p.comments[1:]
+	}
+}
+
+func commentText(comment *ast.Comment) string {
+	text := comment.Token.Literal
+	if strings.HasPrefix(text, "//") {
+		text = strings.TrimRight(text, " \t\r")
+	}
+	return text
+}
+
+// statement print the statement, next is the statement following it in the same block, nil if it is the last one
+func (p *printer) statement(stmt ast.Statement, next ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		if s.IsConst() {
+			p.print("const ")
+		} else {
+			p.print("let ")
+		}
+		p.expression(s.Target(), parser.LOWEST)
+		p.print(" = ")
+		p.expression(s.Value, parser.LOWEST)
+		p.print(";")
+	case *ast.ReturnStatement:
+		p.print("return")
+		if s.ReturnValue != nil {
+			p.print(" ")
+			p.expression(s.ReturnValue, parser.LOWEST)
+		}
+		p.print(";")
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression, parser.LOWEST)
+		if !endsWithBlock(s.Expression) || continuesExpression(next) {
+			p.print(";")
+		}
+	case *ast.FunctionStatement:
+		p.function(s.Function)
+	case *ast.WhileStatement:
+		p.print("while (")
+		p.expression(s.Condition, parser.LOWEST)
+		p.print(") ")
+		p.block(s.Body)
+	case *ast.ForStatement:
+		p.print("for (" + s.Variable.Value + " in ")
+		p.expression(s.Iterable, parser.LOWEST)
+		p.print(") ")
+		p.block(s.Body)
+	case *ast.BreakStatement:
+		p.print("break;")
+	case *ast.ContinueStatement:
+		p.print("continue;")
+	}
+}
+
+// endsWithBlock report whether the expression statement ends with a '}' and so needs no semicolon
+func endsWithBlock(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.IfExpression, *ast.MatchExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+// continuesExpression report whether the statement starts with a token which would extend
+// the expression of the statement before it when there is no semicolon between them, e.g. (x) or -x
+func continuesExpression(stmt ast.Statement) bool {
+	s, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	switch firstChar(s.Expression, parser.LOWEST) {
+	case '(', '[', '-':
+		return true
+	default:
+		return false
+	}
+}
+
+// firstChar return the first character of the expression printed where the precedence prec is required,
+// 0 for an expression starting with a name or a keyword
+func firstChar(expr ast.Expression, prec int) byte {
+	if precedence(expr) < prec {
+		return '('
+	}
+	switch e := expr.(type) {
+	case *ast.InfixExpression:
+		return firstChar(e.LeftExpr, precedence(e))
+	case *ast.AssignExpression:
+		return firstChar(e.Target, parser.CALL)
+	case *ast.CallExpression:
+		return firstChar(e.Function, parser.CALL)
+	case *ast.IndexExpression:
+		return firstChar(e.Left, parser.CALL)
+	case *ast.PrefixExpression:
+		return e.Operator[0]
+	case *ast.Integer, *ast.FloatLiteral:
+		return literal(e)[0]
+	case *ast.StringLiteral:
+		return '"'
+	case *ast.ArrayLiteral, *ast.ArrayPattern:
+		return '['
+	case *ast.HashLiteral, *ast.HashPattern:
+		return '{'
+	case *ast.SpreadExpression:
+		return '.'
+	default:
+		return 0
+	}
+}
+
+// block print the statements of the block indented on their own lines, an empty block is printed as {}
+func (p *printer) block(block *ast.BlockStatement) {
+	if len(block.Statements) == 0 && !p.hasCommentBefore(block.RBrace.Pos) {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.indent++
+	// no blank line after the opening brace
+	p.lastLine = 0
+	p.statements(block.Statements, block.RBrace.Pos)
+	p.indent--
+	p.print("\n" + strings.Repeat(indentation, p.indent) + "}")
+}
+
+// precedence return the binding power of the expression, an operand binding less than its operator requires is parenthesized
+func precedence(expr ast.Expression) int {
+	switch e := expr.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(token.Type(e.Operator))
+	case *ast.AssignExpression:
+		return parser.ASSIGNMENT
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression:
+		return parser.INDEX
+	case *ast.Integer, *ast.FloatLiteral:
+		// a negative literal is made by the macros, it reads as a prefix expression
+		if strings.HasPrefix(literal(e), "-") {
+			return parser.PREFIX
+		}
+	}
+	return primary
+}
+
+// expression print the expression, it is parenthesized when it binds less than prec
+func (p *printer) expression(expr ast.Expression, prec int) {
+	if precedence(expr) < prec {
+		p.print("(")
+		p.expression(expr, parser.LOWEST)
+		p.print(")")
+		return
+	}
+
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		p.print(e.Value)
+	case *ast.Integer, *ast.FloatLiteral:
+		p.print(literal(e))
+	case *ast.StringLiteral:
+		p.print(quote(e.Value))
+	case *ast.Boolean:
+		p.print(strconv.FormatBool(e.Value))
+	case *ast.PrefixExpression:
+		p.print(e.Operator)
+		p.expression(e.RightExpr, parser.PREFIX)
+	case *ast.InfixExpression:
+		// the operators are left associative
+		prec := precedence(e)
+		p.expression(e.LeftExpr, prec)
+		p.print(" " + e.Operator + " ")
+		p.expression(e.RightExpr, prec+1)
+	case *ast.AssignExpression:
+		p.expression(e.Target, parser.CALL)
+		p.print(" " + e.Operator + " ")
+		p.expression(e.Value, parser.LOWEST)
+	case *ast.CallExpression:
+		p.expression(e.Function, parser.CALL)
+		p.print("(")
+		p.expressions(e.Arguments)
+		p.print(")")
+	case *ast.IndexExpression:
+		p.expression(e.Left, parser.CALL)
+		p.print("[")
+		p.expression(e.Index, parser.LOWEST)
+		p.print("]")
+	case *ast.SpreadExpression:
+		p.print("...")
+		p.expression(e.Value, parser.LOWEST)
+	case *ast.ArrayLiteral:
+		p.print("[")
+		p.expressions(e.Elements)
+		p.print("]")
+	case *ast.HashLiteral:
+		p.print("{")
+		for i, pair := range e.Pairs {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expression(pair.Key, parser.LOWEST)
+			p.print(": ")
+			p.expression(pair.Value, parser.LOWEST)
+		}
+		p.print("}")
+	case *ast.IfExpression:
+		p.ifExpression(e)
+	case *ast.MatchExpression:
+		p.matchExpression(e)
+	case *ast.FunctionLiteral:
+		p.function(e)
+	case *ast.MacroLiteral:
+		p.print("macro(")
+		for i, param := range e.Parameters {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(param.Value)
+		}
+		p.print(") ")
+		p.block(e.Body)
+	case *ast.ArrayPattern:
+		p.print("[")
+		for i, element := range e.Elements {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.expression(element, parser.LOWEST)
+		}
+		if e.Rest != nil {
+			if len(e.Elements) > 0 {
+				p.print(", ")
+			}
+			p.print("..." + e.Rest.Value)
+		}
+		p.print("]")
+	case *ast.HashPattern:
+		p.print("{")
+		for i, key := range e.Keys {
+			if i > 0 {
+				p.print(", ")
+			}
+			p.print(key.Value)
+		}
+		p.print("}")
+	}
+}
+
+func (p *printer) expressions(list []ast.Expression) {
+	for i, expr := range list {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expression(expr, parser.LOWEST)
+	}
+}
+
+func (p *printer) ifExpression(expr *ast.IfExpression) {
+	p.print("if (")
+	p.expression(expr.Condition, parser.LOWEST)
+	p.print(") ")
+	p.block(expr.Consequence)
+	if expr.Alternative == nil {
+		return
+	}
+	p.print(" else ")
+	if nested, ok := elseIf(expr.Alternative); ok {
+		p.ifExpression(nested)
+		return
+	}
+	p.block(expr.Alternative)
+}
+
+// elseIf return the if expression which is the only content of the else block, it is printed as else if
+func elseIf(block *ast.BlockStatement) (*ast.IfExpression, bool) {
+	if len(block.Statements) != 1 {
+		return nil, false
+	}
+	stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil, false
+	}
+	nested, ok := stmt.Expression.(*ast.IfExpression)
+	return nested, ok
+}
+
+// matchExpression print every arm on its own line, the arms whose body is a single expression end with a comma
+func (p *printer) matchExpression(expr *ast.MatchExpression) {
+	p.print("match (")
+	p.expression(expr.Subject, parser.LOWEST)
+	p.print(") ")
+	if len(expr.Arms) == 0 && !p.hasCommentBefore(expr.RBrace.Pos) {
+		p.print("{}")
+		return
+	}
+	p.print("{")
+	p.indent++
+	p.lastLine = 0
+	for i, arm := range expr.Arms {
+		pos := arm.Patterns[0].Pos()
+		limit := expr.RBrace.Pos
+		if i+1 < len(expr.Arms) {
+			limit = expr.Arms[i+1].Patterns[0].Pos()
+		}
+		p.commentsBefore(pos)
+		p.startLine(pos.Line)
+		p.expressions(arm.Patterns)
+		if arm.Guard != nil {
+			p.print(" if ")
+			p.expression(arm.Guard, parser.LOWEST)
+		}
+		p.print(" => ")
+		if body, ok := p.armExpression(arm.Body); ok {
+			p.expression(body, parser.LOWEST)
+			p.print(",")
+		} else {
+			p.block(arm.Body)
+		}
+		p.lastLine = arm.Body.End().Line
+		p.trailingComments(arm.Body.End(), limit)
+	}
+	p.commentsBefore(expr.RBrace.Pos)
+	p.indent--
+	p.print("\n" + strings.Repeat(indentation, p.indent) + "}")
+}
+
+// armExpression return the expression which is the whole body of the arm, it is printed without braces
+// unless it starts with '{' and so would be read as a block
+func (p *printer) armExpression(body *ast.BlockStatement) (ast.Expression, bool) {
+	if len(body.Statements) != 1 || p.hasCommentBefore(body.RBrace.Pos) {
+		return nil, false
+	}
+	stmt, ok := body.Statements[0].(*ast.ExpressionStatement)
+	if !ok || firstChar(stmt.Expression, parser.LOWEST) == '{' {
+		return nil, false
+	}
+	return stmt.Expression, true
+}
+
+func (p *printer) function(fn *ast.FunctionLiteral) {
+	p.print("fn")
+	if fn.Name != nil {
+		p.print(" " + fn.Name.Value)
+	}
+	p.print("(")
+	for i, param := range fn.Parameters {
+		if i > 0 {
+			p.print(", ")
+		}
+		p.expression(param, parser.LOWEST)
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			p.print(" = ")
+			p.expression(fn.Defaults[i], parser.LOWEST)
+		}
+	}
+	if fn.Rest != nil {
+		if len(fn.Parameters) > 0 {
+			p.print(", ")
+		}
+		p.print("..." + fn.Rest.Value)
+	}
+	p.print(") ")
+	p.block(fn.Body)
+}
+
+// literal return the source of the number literal, the literals made by the macros have no token literal
+func literal(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.Integer:
+		if e.Token.Literal != "" {
+			return e.Token.Literal
+		}
+		if e.Big != nil {
+			return e.Big.String()
+		}
+		return strconv.FormatInt(e.Value, 10)
+	case *ast.FloatLiteral:
+		if e.Token.Literal != "" {
+			return e.Token.Literal
+		}
+		s := strconv.FormatFloat(e.Value, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	default:
+		return ""
+	}
+}
+
+// quote return the string literal of s, using the escapes understood by the lexer
+func quote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			// not valid UTF-8, the byte is kept as it is
+			out.WriteByte(s[i])
+		case r == '"':
+			out.WriteString(`\"`)
+		case r == '\\':
+			out.WriteString(`\\`)
+		case r == '\n':
+			out.WriteString(`\n`)
+		case r == '\t':
+			out.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			out.WriteString(`\u{` + strconv.FormatInt(int64(r), 16) + `}`)
+		default:
+			out.WriteRune(r)
+		}
+		i += size
+	}
+	out.WriteByte('"')
+	return out.String()
+}
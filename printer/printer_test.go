@@ -0,0 +1,187 @@
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/GzzyZm/interpreter/ast"
+	"github.com/GzzyZm/interpreter/lexer"
+	"github.com/GzzyZm/interpreter/parser"
+	"github.com/GzzyZm/interpreter/token"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"let   x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"const c = 1; return c", "const c = 1;\nreturn c;\n"},
+		{
+			"(1 + 2) * 3; 1 + (2 * 3); a - (b - c); (a - b) - c; -(a + b); !(-a)",
+			"(1 + 2) * 3;\n1 + 2 * 3;\na - (b - c);\na - b - c;\n-(a + b);\n!-a;\n",
+		},
+		{
+			"(-a)[0]; -a[0]; f(x)[0](y); (a || b) && c; a || b && c",
+			"(-a)[0];\n-a[0];\nf(x)[0](y);\n(a || b) && c;\na || b && c;\n",
+		},
+		{"x = y = 2; x += (y = 3) * 2; a[i] -= 1", "x = y = 2;\nx += (y = 3) * 2;\na[i] -= 1;\n"},
+		{`puts("a\"b\\c\nd\te")`, `puts("a\"b\\c\nd\te");` + "\n"},
+		{"[1, 2.5, 1e3, true]; {\"a\": [], 1: {}}", "[1, 2.5, 1e3, true];\n{\"a\": [], 1: {}};\n"},
+		{
+			"let add = fn(a, b = 1, ...rest) { a + b }; add(1, ...[2])",
+			"let add = fn(a, b = 1, ...rest) {\n    a + b;\n};\nadd(1, ...[2]);\n",
+		},
+		{
+			"fn max(a, b) { if (a > b) { return a; } b } let [x, {y}, ...zs] = v; let f = fn() {}",
+			"fn max(a, b) {\n    if (a > b) {\n        return a;\n    }\n    b;\n}\nlet [x, {y}, ...zs] = v;\nlet f = fn() {};\n",
+		},
+		{
+			"if (a) { 1 } else if (b) { 2 } else { if (c) { 3 } }",
+			"if (a) {\n    1;\n} else if (b) {\n    2;\n} else if (c) {\n    3;\n}\n",
+		},
+		{
+			"while (i < 3) { i += 1; if (i == 1) { continue; } break; }",
+			"while (i < 3) {\n    i += 1;\n    if (i == 1) {\n        continue;\n    }\n    break;\n}\n",
+		},
+		{
+			"for (k in range(3)) { puts(k) }",
+			"for (k in range(3)) {\n    puts(k);\n}\n",
+		},
+		{
+			"match (x) { 1, -2 => \"small\"; n if n > 3 => { puts(n); n } _ => { {\"a\": 1} } }",
+			"match (x) {\n    1, -2 => \"small\",\n    n if n > 3 => {\n        puts(n);\n        n;\n    }\n    _ => {\n        {\"a\": 1};\n    }\n}\n",
+		},
+		{"let m = match (x) {}; m", "let m = match (x) {};\nm;\n"},
+		{
+			"let m = macro(a, b) { quote(unquote(b) - unquote(a)) };",
+			"let m = macro(a, b) {\n    quote(unquote(b) - unquote(a));\n};\n",
+		},
+		{
+			// without the semicolon the following statements would continue the if expression
+			"if (a) { 1 }; [1]; if (b) { 2 }; -x; if (c) { 3 } x",
+			"if (a) {\n    1;\n};\n[1];\nif (b) {\n    2;\n};\n-x;\nif (c) {\n    3;\n}\nx;\n",
+		},
+		{
+			"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;",
+			"let a = 1;\n\nlet b = 2;\nlet c = 3;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		testFormat(t, tt.input, tt.expected)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// only a comment", "// only a comment\n"},
+		{
+			"// header\n\nlet x = 1; // one   \nlet y = 2; /* two */ let z = 3;\n/* tail */",
+			"// header\n\nlet x = 1; // one\nlet y = 2; /* two */\nlet z = 3;\n/* tail */\n",
+		},
+		{
+			"let f = fn() { // start\n  x; // x\n\n  // before y\n  y\n  // end\n} // after",
+			"let f = fn() {\n    // start\n    x; // x\n\n    // before y\n    y;\n    // end\n}; // after\n",
+		},
+		{"fn f() { /* nothing */ }", "fn f() {\n    /* nothing */\n}\n"},
+		{"let a = [1, // one\n2];\nlet b = 3;", "let a = [1, 2]; // one\nlet b = 3;\n"},
+		{
+			"match (x) {\n  1 => a, // one\n  // other\n  _ => b\n}",
+			"match (x) {\n    1 => a, // one\n    // other\n    _ => b,\n}\n",
+		},
+		{
+			"let x = 1 /* inner */ + 2;\n/* multi\n   line */\nx",
+			"let x = 1 + 2; /* inner */\n/* multi\n   line */\nx;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		testFormat(t, tt.input, tt.expected)
+	}
+}
+
+// testFormat check that the input is formatted as expected, that the expected source is formatted as itself
+// and that the formatted source parses to the same program
+func testFormat(t *testing.T, input string, expected string) {
+	t.Helper()
+	formatted, err := Format("test.mk", []byte(input))
+	if err != nil {
+		t.Errorf("%q: unexpected error: %v", input, err)
+		return
+	}
+	if string(formatted) != expected {
+		t.Errorf("%q: formatted wrong.\nwant=%q\ngot= %q", input, expected, formatted)
+		return
+	}
+
+	again, err := Format("test.mk", formatted)
+	if err != nil {
+		t.Errorf("%q: formatted source doesn't parse: %v", input, err)
+		return
+	}
+	if !bytes.Equal(again, formatted) {
+		t.Errorf("%q: formatting isn't stable.\nfirst= %q\nsecond=%q", input, formatted, again)
+	}
+
+	if original, reparsed := parse(input).PrintNode(), parse(string(formatted)).PrintNode(); original != reparsed {
+		t.Errorf("%q: formatting changed the program.\nwant=%q\ngot= %q", input, original, reparsed)
+	}
+}
+
+func parse(input string) *ast.Program {
+	return parser.New(lexer.New(input)).ParseProgram()
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Format("bad.mk", []byte("let = 1;\nlet y = ;"))
+	var errs parser.ErrorList
+	if !errors.As(err, &errs) {
+		t.Fatalf("error is not a parser.ErrorList. got=%T (%v)", err, err)
+	}
+	if len(errs) != 2 || errs[0].Pos.String() != "bad.mk:1:5" {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestFprintNode(t *testing.T) {
+	integer := func(value int64) *ast.Integer { return &ast.Integer{Value: value} }
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{
+			&ast.InfixExpression{
+				LeftExpr:  integer(2),
+				Operator:  "*",
+				RightExpr: &ast.InfixExpression{LeftExpr: integer(1), Operator: "+", RightExpr: integer(-3)},
+			},
+			"2 * (1 + -3)",
+		},
+		{&ast.IndexExpression{Left: integer(-1), Index: &ast.FloatLiteral{Value: 2}}, "(-1)[2.0]"},
+		{&ast.StringLiteral{Value: "tab\there\x01"}, `"tab\there\u{1}"`},
+		{
+			&ast.BlockStatement{Statements: []ast.Statement{
+				&ast.LetStatement{Token: token.New(token.LET, "let"), Name: &ast.Identifier{Value: "x"}, Value: integer(1)},
+				&ast.ReturnStatement{ReturnValue: &ast.Identifier{Value: "x"}},
+			}},
+			"{\n    let x = 1;\n    return x;\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Fprint(&out, tt.node); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("printed wrong. want=%q, got=%q", tt.expected, out.String())
+		}
+	}
+}
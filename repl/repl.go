@@ -3,6 +3,7 @@ package repl
 import (
 	"bufio"
 	"fmt"
+	"github.com/GzzyZm/interpreter/diagnostics"
 	"github.com/GzzyZm/interpreter/evaluator"
 	"github.com/GzzyZm/interpreter/object"
 	"github.com/GzzyZm/interpreter/parser"
@@ -18,7 +19,11 @@ func StartREPL(input io.Reader, output io.Writer) {
 	// read the user's input from the input stream
 	scanner := bufio.NewScanner(input)
 	env := object.NewEnv()
-	for {
+	// the macros are kept apart from the bindings the programs see at runtime
+	macroEnv := object.NewEnv()
+	evaluator.Output = output
+	color := diagnostics.ColorEnabled(output)
+	for line := 1; ; line++ {
 		// outputs the identity >>  before user input
 		if _, err := fmt.Fprint(output, PROMPT); err != nil {
 			panic("print >> Error")
@@ -28,15 +33,29 @@ func StartREPL(input io.Reader, output io.Writer) {
 			panic("scan Error")
 		}
 		inputContext := scanner.Text()
-		l := lexer.New(inputContext)
+		// every input gets its own file name, so errors raised by functions defined earlier don't point into this input
+		filename := fmt.Sprintf("<repl:%d>", line)
+		l := lexer.NewFile(filename, inputContext)
 		p := parser.New(l)
 		program := p.ParseProgram()
+		renderer := diagnostics.NewFileRenderer(filename, inputContext, color)
 		if len(p.Errors()) != 0 {
-			printParserErrors(output, p.Errors())
+			printParserErrors(output, renderer, p.Errors())
 			continue
 		}
 
-		obj := evaluator.Eval(program, env)
+		evaluator.DefineMacros(program, macroEnv)
+		expanded, expandErr := evaluator.ExpandMacros(program, macroEnv)
+		if expandErr != nil {
+			printRuntimeError(output, renderer, expandErr)
+			continue
+		}
+
+		obj := evaluator.Eval(expanded, env)
+		if errObj, ok := obj.(*object.Error); ok {
+			printRuntimeError(output, renderer, errObj)
+			continue
+		}
 		if obj != nil {
 			if _, err := io.WriteString(output, fmt.Sprintf("%s\n", obj.Inspect())); err != nil {
 				panic("output Error")
@@ -45,10 +64,17 @@ func StartREPL(input io.Reader, output io.Writer) {
 	}
 }
 
-func printParserErrors(output io.Writer, errors []string) {
-	for _, msg := range errors {
-		if _, err := io.WriteString(output, fmt.Sprintf("Woops! a parser error has occurred:\n \t%s\n", msg)); err != nil {
+func printParserErrors(output io.Writer, renderer *diagnostics.Renderer, errors parser.ErrorList) {
+	for _, parseErr := range errors {
+		if err := renderer.Render(output, diagnostics.FromParseError(parseErr)); err != nil {
 			panic("print parser errors Error")
 		}
 	}
 }
+
+func printRuntimeError(output io.Writer, renderer *diagnostics.Renderer, errObj *object.Error) {
+	d := diagnostics.FromError(errObj)
+	if err := renderer.Render(output, d); err != nil {
+		panic("print runtime error Error")
+	}
+}
@@ -1,14 +1,19 @@
 package token
 
+import "fmt"
+
 const (
 	ILLEGAL = "ILLEGAL" // unknown
 	EOF     = "EOF"     // end of file
+	COMMENT = "COMMENT" // line or block comment, only produced on request
 
 	// Identifier
 	IDENTIFIER = "IDENTIFIER" // e.g. variable name: x、y, function name: max、add
 
 	// Literals
-	INT = "INT"
+	INT    = "INT"
+	FLOAT  = "FLOAT"
+	STRING = "STRING"
 
 	// Operator
 	ASSIGN   = "="
@@ -17,48 +22,111 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
-	LT       = "<"
-	GT       = ">"
-	EQ       = "=="
-	NOT_EQ   = "!="
+	PERCENT  = "%"
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+
+	LT     = "<"
+	GT     = ">"
+	LT_EQ  = "<="
+	GT_EQ  = ">="
+	EQ     = "=="
+	NOT_EQ = "!="
+	AND    = "&&"
+	OR     = "||"
 
 	// Delimiter
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	ARROW     = "=>"
+	ELLIPSIS  = "..."
 
 	LPAREN = "("
 	RPAREN = ")"
 	LBRACE = "{"
 	RBRACE = "}"
 
+	LBRACKET = "["
+	RBRACKET = "]"
+
 	// Keyword
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	MATCH    = "MATCH"
+	MACRO    = "MACRO"
 )
 
 // keywords map
 var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"const":    CONST,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"match":    MATCH,
+	"macro":    MACRO,
 }
 
 // Type lexical unit type
 type Type string
 
+// Position describes a location in the source text
+type Position struct {
+	Filename string // file name, empty if the source has no file
+	Offset   int    // byte offset, starting at 0
+	Line     int    // line number, starting at 1
+	Column   int    // column number in bytes, starting at 1
+}
+
+// IsValid reports whether the position has been set by the lexer
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position in the form file:line:column, line:column or "-" if unknown
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
 // Token lexical unit token
 type Token struct {
 	Type    Type
-	Literal string // lexical unit literals
+	Literal string   // lexical unit literals
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the token
 }
 
 func New[T string | byte](t Type, ch T) Token {